@@ -1,7 +1,10 @@
 package main
 
-import "Backend/internal/server"
+import (
+	"Backend/internal/cli"
+	"os"
+)
 
 func main() {
-	server.Serve()
+	os.Exit(cli.Run(os.Args[1:]))
 }
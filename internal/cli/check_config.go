@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"Backend/internal/env"
+	"Backend/internal/models"
+	"Backend/internal/objectstore"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+func checkConfigCommand() *command {
+	return &command{
+		name:    "check-config",
+		summary: "Check the settings, the database and the object store.",
+		flags: func(fs *flag.FlagSet) {
+			e := env.GetStaticEnv()
+			fs.IntVar(&e.ServerPort, "port", e.ServerPort, "port to listen on (SERVER_PORT)")
+			fs.StringVar(&e.PublicBaseUrl, "public-base-url", e.PublicBaseUrl, "address the server is reached at (PUBLIC_BASE_URL)")
+			databaseFlags(fs)
+			objectStoreFlags(fs)
+		},
+		run: func(ctx context.Context, fs *flag.FlagSet) error {
+			if fs.NArg() > 0 {
+				return errUsage
+			}
+
+			e := env.GetStaticEnv()
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			failed := 0
+			check := func(name string, err error) {
+				if err != nil {
+					failed++
+					fmt.Fprintf(w, "FAIL\t%s\t%v\n", name, err)
+					return
+				}
+				fmt.Fprintf(w, "ok\t%s\t\n", name)
+			}
+			// For settings of optional features, which serve starts without
+			warn := func(name string, err error) {
+				if err != nil {
+					fmt.Fprintf(w, "WARN\t%s\t%v\n", name, err)
+					return
+				}
+				fmt.Fprintf(w, "ok\t%s\t\n", name)
+			}
+
+			check("server port", positive(e.ServerPort, "SERVER_PORT"))
+			warn("public base url", e.CheckPublicBaseUrl())
+			warn("label target url", e.CheckLabelTargetUrl())
+			check("token ttl", positive(int(e.TokenTtl), "TOKEN_TTL"))
+			check("trash retention", positive(int(e.TrashRetention), "TRASH_RETENTION"))
+			check("trash purge interval", positive(int(e.TrashPurgeInterval), "TRASH_PURGE_INTERVAL"))
+			check("admin account", adminCredentials(e))
+
+			db, err := openDatabase(ctx)
+			check(fmt.Sprintf("%s database", e.DbDriver), err)
+			if err == nil {
+				_ = db.Disconnect(ctx)
+			}
+
+			objStore, err := objectstore.NewObjectStore()
+			if err == nil {
+				_, err = objStore.ListObjects(ctx, "labels/")
+			}
+			check(fmt.Sprintf("%s object store", e.ObjectStore), err)
+
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d checks failed", failed)
+			}
+			return nil
+		},
+	}
+}
+
+func positive(value int, name string) error {
+	if value <= 0 {
+		return fmt.Errorf("%s has to be set to more than 0", name)
+	}
+	return nil
+}
+
+// adminCredentials
+// checks the account that serve creates on an empty database, an unset password only skips it.
+func adminCredentials(e *env.StaticEnvStruct) error {
+	if e.AdminPassword == "" {
+		return nil
+	}
+	if _, err := models.NewUser(e.AdminUsername, e.AdminPassword); err != nil {
+		return errors.Join(errors.New("ADMIN_USERNAME or ADMIN_PASSWORD is invalid"), err)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/env"
+	"Backend/internal/server"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// command
+// is a subcommand of the backend binary. Its flags override the values loaded from the
+// environment, see env.GetStaticEnv, so they have to be parsed before anything reads them.
+type command struct {
+	name    string
+	args    string // Positional arguments as shown in the usage
+	summary string
+	help    string // Details shown with -h below the summary
+	flags   func(fs *flag.FlagSet)
+	run     func(ctx context.Context, fs *flag.FlagSet) error
+}
+
+// errUsage is returned by commands whose arguments do not fit, the usage has been printed already
+var errUsage = errors.New("invalid usage")
+
+func commands() []*command {
+	return []*command{
+		serveCommand(),
+		migrateCommand(),
+		createUserCommand(),
+		importCommand(),
+		exportCommand(),
+		gcImagesCommand(),
+		regenThumbnailsCommand(),
+		checkConfigCommand(),
+	}
+}
+
+// Run
+// executes the subcommand named by the first argument, serve when there is none,
+// and returns the exit code of the process.
+func Run(args []string) int {
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	for _, cmd := range commands() {
+		if cmd.name != name {
+			continue
+		}
+
+		fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: backend %s [flags] %s\n\n%s\n", cmd.name, cmd.args, cmd.summary)
+			if cmd.help != "" {
+				fmt.Fprintf(fs.Output(), "%s\n", cmd.help)
+			}
+			fmt.Fprintf(fs.Output(), "\nFlags:\n")
+			fs.PrintDefaults()
+		}
+		if cmd.flags != nil {
+			cmd.flags(fs)
+		}
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return 0
+			}
+			return 2
+		}
+
+		if err := cmd.run(context.Background(), fs); err != nil {
+			if errors.Is(err, errUsage) {
+				fs.Usage()
+				return 2
+			}
+			fmt.Fprintf(os.Stderr, "backend %s: %v\n", cmd.name, err)
+			return 1
+		}
+		return 0
+	}
+
+	if name != "help" {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+	}
+	printCommands()
+	if name != "help" {
+		return 2
+	}
+	return 0
+}
+
+func printCommands() {
+	fmt.Fprintln(os.Stderr, "Usage: backend <command> [flags]\n\nCommands:")
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %s\t%s\n", cmd.name, cmd.summary)
+	}
+	_ = w.Flush()
+	fmt.Fprintln(os.Stderr, "\nRun backend <command> -h for the flags of a command.")
+}
+
+////////////////////////////////////////////////
+// Shared flags
+////////////////////////////////////////////////
+
+// databaseFlags
+// lets the flags override the database settings of the environment.
+func databaseFlags(fs *flag.FlagSet) {
+	e := env.GetStaticEnv()
+	fs.StringVar(&e.DbDriver, "db-driver", e.DbDriver, "database `driver`, postgres or sqlite (DB_DRIVER)")
+	fs.StringVar(&e.DbPath, "db-path", e.DbPath, "database file when the driver is sqlite (DB_PATH)")
+	fs.StringVar(&e.DbHost, "db-host", e.DbHost, "Postgres host (DB_HOST)")
+	fs.IntVar(&e.DbPort, "db-port", e.DbPort, "Postgres port (DB_PORT)")
+	fs.StringVar(&e.DbUser, "db-user", e.DbUser, "Postgres user (DB_USER)")
+	fs.StringVar(&e.DbName, "db-name", e.DbName, "Postgres database (DB_NAME)")
+	// Secrets are not shown as defaults in the usage
+	fs.Func("db-password", "Postgres password (DB_PASSWORD)", func(s string) error {
+		e.DbPassword = s
+		return nil
+	})
+}
+
+// objectStoreFlags
+// lets the flags override the object store settings of the environment.
+func objectStoreFlags(fs *flag.FlagSet) {
+	e := env.GetStaticEnv()
+	fs.StringVar(&e.ObjectStore, "object-store", e.ObjectStore, "object store `backend`, minio, local or memory (OBJECT_STORE)")
+	fs.StringVar(&e.ObjectStorePath, "object-store-path", e.ObjectStorePath, "directory of the local object store (OBJECT_STORE_PATH)")
+	fs.StringVar(&e.MinioHost, "minio-host", e.MinioHost, "MinIO host (MINIO_HOST)")
+	fs.StringVar(&e.MinioPort, "minio-port", e.MinioPort, "MinIO API port (MINIO_PORT_API)")
+	fs.StringVar(&e.MinioUser, "minio-user", e.MinioUser, "MinIO user (MINIO_USER)")
+	fs.StringVar(&e.MinioBucket, "minio-bucket", e.MinioBucket, "MinIO bucket (MINIO_BUCKET)")
+	fs.Func("minio-password", "MinIO password (MINIO_PASSWORD)", func(s string) error {
+		e.MinioPassword = s
+		return nil
+	})
+}
+
+////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////
+
+// openDatabase
+// connects to the database of the environment, which has to be on the schema of this binary.
+// Unlike serve, chores do not migrate on their own.
+func openDatabase(ctx context.Context) (database.Repository, error) {
+	db, err := server.OpenDatabase()
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := db.QueryMigrations(ctx)
+	if errors.Is(err, database.ErrNoMigrationsTable) {
+		_ = db.Disconnect(ctx)
+		return nil, fmt.Errorf("%w, run backend migrate up first", err)
+	}
+	if err != nil {
+		_ = db.Disconnect(ctx)
+		return nil, err
+	}
+	for _, m := range migrations {
+		if m.Unknown {
+			_ = db.Disconnect(ctx)
+			return nil, fmt.Errorf("%w, version %d_%s is applied", database.ErrSchemaTooNew, m.Version, m.Name)
+		}
+		if m.AppliedAt == nil {
+			_ = db.Disconnect(ctx)
+			return nil, fmt.Errorf("migration %04d_%s is pending, run backend migrate up first", m.Version, m.Name)
+		}
+	}
+
+	return db, nil
+}
+
+// inWorkspace
+// scopes the context to the workspace, which has to exist. Changes are recorded as made by the CLI.
+func inWorkspace(ctx context.Context, db database.Repository, workspaceId string) (context.Context, error) {
+	if workspaceId == "" {
+		return nil, errors.New("-workspace is required")
+	}
+
+	ids, err := db.QueryWorkspaceIds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if id == workspaceId {
+			return database.WithActor(database.WithWorkspace(ctx, workspaceId), cliActor), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", database.ErrWorkspaceNotFound, workspaceId)
+}
+
+// cliActor is recorded in the entity history for changes made by commands
+const cliActor = "cli"
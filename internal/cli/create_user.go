@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"Backend/internal/models"
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func createUserCommand() *command {
+	var username, password, workspaceId, role, newWorkspace string
+
+	return &command{
+		name:    "create-user",
+		summary: "Create an account.",
+		help: "The password is read from the first line of stdin unless given. The user can join\n" +
+			"an existing workspace or get a new one of their own.",
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&username, "username", "", "name to log in with, required")
+			fs.StringVar(&password, "password", "", "password, visible to other users of the machine, prefer stdin")
+			fs.StringVar(&workspaceId, "workspace", "", "`id` of a workspace to add the user to")
+			fs.StringVar(&role, "role", string(models.RoleEditor), "role in the workspace given by -workspace, viewer, editor or owner")
+			fs.StringVar(&newWorkspace, "new-workspace", "", "`name` of a workspace to create with the user as owner")
+			databaseFlags(fs)
+		},
+		run: func(ctx context.Context, fs *flag.FlagSet) error {
+			if fs.NArg() > 0 || username == "" {
+				return errUsage
+			}
+			memberRole, err := models.ParseRole(role)
+			if err != nil {
+				return err
+			}
+
+			if password == "" {
+				line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+				if err != nil && line == "" {
+					return errors.New("no password given on stdin")
+				}
+				password = strings.TrimRight(line, "\r\n")
+			}
+
+			user, err := models.NewUser(username, password)
+			if err != nil {
+				return err
+			}
+
+			db, err := openDatabase(ctx)
+			if err != nil {
+				return err
+			}
+			defer db.Disconnect(ctx)
+
+			if workspaceId != "" {
+				if _, err := inWorkspace(ctx, db, workspaceId); err != nil {
+					return err
+				}
+			}
+
+			if err := db.CreateUser(ctx, user); err != nil {
+				return err
+			}
+			fmt.Printf("Created user %s (%s)\n", user.Username, user.Id)
+
+			if workspaceId != "" {
+				if _, err := db.AddWorkspaceMember(ctx, workspaceId, user.Username, memberRole); err != nil {
+					return err
+				}
+				fmt.Printf("Added %s to workspace %s as %s\n", user.Username, workspaceId, memberRole)
+			}
+
+			if newWorkspace != "" {
+				workspace, err := db.CreateWorkspace(ctx, newWorkspace, user.Id)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Created workspace %s (%s)\n", workspace.Name, workspace.Id)
+			}
+
+			return nil
+		},
+	}
+}
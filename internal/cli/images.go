@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"Backend/internal/images"
+	"Backend/internal/objectstore"
+	"context"
+	"flag"
+	"fmt"
+	"time"
+)
+
+func gcImagesCommand() *command {
+	var minAge time.Duration
+	var dryRun bool
+
+	return &command{
+		name:    "gc-images",
+		summary: "Delete thumbnails that no entity refers to anymore.",
+		help:    "Entities in the trash still refer to their thumbnails.",
+		flags: func(fs *flag.FlagSet) {
+			fs.DurationVar(&minAge, "min-age", 24*time.Hour, "keep thumbnails younger than this, their entity may still be in the making")
+			fs.BoolVar(&dryRun, "dry-run", false, "only list what would be deleted")
+			databaseFlags(fs)
+			objectStoreFlags(fs)
+		},
+		run: func(ctx context.Context, fs *flag.FlagSet) error {
+			if fs.NArg() > 0 {
+				return errUsage
+			}
+
+			db, err := openDatabase(ctx)
+			if err != nil {
+				return err
+			}
+			defer db.Disconnect(ctx)
+
+			objStore, err := objectstore.NewObjectStore()
+			if err != nil {
+				return err
+			}
+
+			report, err := images.CollectGarbage(ctx, db, objStore, minAge, dryRun)
+			if report != nil {
+				for _, name := range report.Deleted {
+					fmt.Println(name)
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			verb := "Deleted"
+			if dryRun {
+				verb = "Would delete"
+			}
+			fmt.Printf("%s %d thumbnails, kept %d in use and %d younger than %v\n",
+				verb, len(report.Deleted), report.Kept, report.Young, minAge)
+			return nil
+		},
+	}
+}
+
+func regenThumbnailsCommand() *command {
+	return &command{
+		name:    "regen-thumbnails",
+		summary: "Render every thumbnail size again from the largest one.",
+		flags: func(fs *flag.FlagSet) {
+			databaseFlags(fs)
+			objectStoreFlags(fs)
+		},
+		run: func(ctx context.Context, fs *flag.FlagSet) error {
+			if fs.NArg() > 0 {
+				return errUsage
+			}
+
+			db, err := openDatabase(ctx)
+			if err != nil {
+				return err
+			}
+			defer db.Disconnect(ctx)
+
+			objStore, err := objectstore.NewObjectStore()
+			if err != nil {
+				return err
+			}
+
+			regenerated, skipped, err := images.RegenerateThumbnails(ctx, db, objStore)
+			fmt.Printf("Regenerated %d images, skipped %d without their largest size\n", regenerated, len(skipped))
+			return err
+		},
+	}
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/server"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+func migrateCommand() *command {
+	var force bool
+
+	return &command{
+		name:    "migrate",
+		args:    "up | down [n] | status",
+		summary: "Apply, roll back or list schema migrations.",
+		help: "up applies all pending migrations, down rolls back the latest n, 1 by default,\n" +
+			"and status lists the migrations and whether they are applied. Rolling back the\n" +
+			"baseline migration drops every table and needs -force.",
+		flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&force, "force", false, "allow rolling back the baseline migration, which drops every table")
+			databaseFlags(fs)
+		},
+		run: func(ctx context.Context, fs *flag.FlagSet) error {
+			if fs.NArg() == 0 {
+				return errUsage
+			}
+
+			// Not openDatabase, this is the command that brings the schema up to date
+			db, err := server.OpenDatabase()
+			if err != nil {
+				return err
+			}
+			defer db.Disconnect(ctx)
+
+			switch fs.Arg(0) {
+			case "up":
+				if fs.NArg() != 1 {
+					return errUsage
+				}
+				if err := db.Migrate(ctx); err != nil {
+					return err
+				}
+				return printMigrations(ctx, db)
+
+			case "down":
+				steps := 1
+				if fs.NArg() > 2 {
+					return errUsage
+				}
+				if fs.NArg() == 2 {
+					if steps, err = strconv.Atoi(fs.Arg(1)); err != nil || steps < 1 {
+						return fmt.Errorf("invalid number of migrations %q", fs.Arg(1))
+					}
+				}
+				for i := 0; i < steps; i++ {
+					m, err := db.RollbackMigration(ctx, force)
+					if errors.Is(err, database.ErrBaselineRollback) {
+						return fmt.Errorf("%w, pass -force to roll it back anyway", err)
+					}
+					if err != nil {
+						return err
+					}
+					fmt.Printf("Rolled back %04d_%s\n", m.Version, m.Name)
+				}
+				return nil
+
+			case "status":
+				if fs.NArg() != 1 {
+					return errUsage
+				}
+				return printMigrations(ctx, db)
+
+			default:
+				return errUsage
+			}
+		},
+	}
+}
+
+func printMigrations(ctx context.Context, db database.Repository) error {
+	migrations, err := db.QueryMigrations(ctx)
+	if errors.Is(err, database.ErrNoMigrationsTable) {
+		fmt.Println("No migrations table, run backend migrate up to create the schema")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+	for _, m := range migrations {
+		applied := "pending"
+		if m.AppliedAt != nil {
+			applied = m.AppliedAt.Local().Format(time.DateTime)
+		}
+		if m.Unknown {
+			applied += " (unknown to this binary)"
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", m.Version, m.Name, applied)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"Backend/internal/env"
+	"Backend/internal/server"
+	"context"
+	"flag"
+)
+
+func serveCommand() *command {
+	return &command{
+		name:    "serve",
+		summary: "Start the server, applying pending migrations first.",
+		flags: func(fs *flag.FlagSet) {
+			e := env.GetStaticEnv()
+			fs.IntVar(&e.ServerPort, "port", e.ServerPort, "port to listen on (SERVER_PORT)")
+			fs.StringVar(&e.PublicBaseUrl, "public-base-url", e.PublicBaseUrl, "address the server is reached at (PUBLIC_BASE_URL)")
+			fs.BoolVar(&e.ImagePublic, "image-public", e.ImagePublic, "serve images without authentication (IMAGE_PUBLIC)")
+			databaseFlags(fs)
+			objectStoreFlags(fs)
+		},
+		run: func(ctx context.Context, fs *flag.FlagSet) error {
+			if fs.NArg() > 0 {
+				return errUsage
+			}
+			return server.Serve()
+		},
+	}
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"Backend/internal/objectstore"
+	"Backend/internal/transfer"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func exportCommand() *command {
+	var workspaceId, output string
+	var noImages bool
+
+	return &command{
+		name:    "export",
+		summary: "Export the entities of a workspace to a zip archive.",
+		help: "The archive holds the entities with their tags, attribute schema and images,\n" +
+			"it is read by import. The trash, history and loans are not exported.",
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&workspaceId, "workspace", "", "`id` of the workspace to export, required")
+			fs.StringVar(&output, "o", "-", "`file` to write the archive to, - for stdout")
+			fs.BoolVar(&noImages, "no-images", false, "leave the images out of the archive")
+			databaseFlags(fs)
+			objectStoreFlags(fs)
+		},
+		run: func(ctx context.Context, fs *flag.FlagSet) error {
+			if fs.NArg() > 0 {
+				return errUsage
+			}
+
+			db, err := openDatabase(ctx)
+			if err != nil {
+				return err
+			}
+			defer db.Disconnect(ctx)
+
+			wsCtx, err := inWorkspace(ctx, db, workspaceId)
+			if err != nil {
+				return err
+			}
+			objStore, err := objectstore.NewObjectStore()
+			if err != nil {
+				return err
+			}
+
+			var w io.Writer = os.Stdout
+			if output != "-" {
+				f, err := os.Create(output)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+
+			manifest, err := transfer.Export(wsCtx, db, objStore, w, !noImages)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Exported %d entities of workspace %s\n", len(manifest.Entities), workspaceId)
+			return nil
+		},
+	}
+}
+
+func importCommand() *command {
+	var workspaceId string
+
+	return &command{
+		name:    "import",
+		args:    "<archive.zip>",
+		summary: "Import an archive written by export into a workspace.",
+		help: "Ids and codes are kept so that printed labels stay valid, an archive\n" +
+			"cannot be imported twice into one database.",
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&workspaceId, "workspace", "", "`id` of the workspace to import into, required")
+			databaseFlags(fs)
+			objectStoreFlags(fs)
+		},
+		run: func(ctx context.Context, fs *flag.FlagSet) error {
+			if fs.NArg() != 1 {
+				return errUsage
+			}
+
+			f, err := os.Open(fs.Arg(0))
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			info, err := f.Stat()
+			if err != nil {
+				return err
+			}
+
+			db, err := openDatabase(ctx)
+			if err != nil {
+				return err
+			}
+			defer db.Disconnect(ctx)
+
+			wsCtx, err := inWorkspace(ctx, db, workspaceId)
+			if err != nil {
+				return err
+			}
+			objStore, err := objectstore.NewObjectStore()
+			if err != nil {
+				return err
+			}
+
+			manifest, err := transfer.Import(wsCtx, db, objStore, f, info.Size())
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Imported %d entities into workspace %s\n", len(manifest.Entities), workspaceId)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,21 @@
+package database
+
+import "context"
+
+type actorContextKey struct{}
+
+// SystemActor is recorded for changes made outside of a request, e.g. by the trash janitor
+const SystemActor = "system"
+
+// WithActor
+// returns a context whose changes are recorded in the entity history under the given actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorContextKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
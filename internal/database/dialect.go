@@ -0,0 +1,37 @@
+package database
+
+import "gorm.io/gorm"
+
+// Names of the gorm dialectors, the migrations of each are kept in a directory of that name
+const (
+	dialectPostgres = "postgres"
+	dialectSqlite   = "sqlite"
+)
+
+// dialect
+// holds the SQL that cannot be written portably. GormAdapter runs the same queries on
+// every database and asks the dialect of its connection for the rest.
+type dialect interface {
+	// name of the gorm dialector
+	name() string
+
+	// search selects searchRow of the live entities of the workspace matching the query, best first
+	search(tx *gorm.DB, query string, workspaceId string, limit int) *gorm.DB
+
+	// numberAttribute is the attribute of entities as a number, NULL when it is not one
+	numberAttribute(key string) (string, []any)
+
+	// textAttribute is the attribute of entities as text, booleans as true and false
+	textAttribute(key string, boolean bool) (string, []any)
+
+	// lock serializes the transactions taking it on the key until they end.
+	// A no-op where transactions take the write lock when they begin.
+	lock(tx *gorm.DB, key int64) error
+}
+
+func dialectOf(tx *gorm.DB) dialect {
+	if tx.Dialector.Name() == dialectSqlite {
+		return sqliteDialect{}
+	}
+	return postgresDialect{}
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+)
+
+// postgresDialect uses the full-text search, jsonb operators and advisory locks of Postgres
+type postgresDialect struct{}
+
+// searchDocument is the text search vector of an entity aliased as e
+const searchDocument = "to_tsvector('english', coalesce(e.name, '') || ' ' || coalesce(e.description, ''))"
+
+// searchIndexDocument is searchDocument without the alias, which index expressions cannot refer to
+const searchIndexDocument = "to_tsvector('english', coalesce(name, '') || ' ' || coalesce(description, ''))"
+
+// searchIndexDdl creates the GIN index backing SearchEntities on Postgres
+const searchIndexDdl = "CREATE INDEX IF NOT EXISTS idx_entities_search ON entities USING GIN (" + searchIndexDocument + ")"
+
+func (postgresDialect) name() string {
+	return dialectPostgres
+}
+
+// search
+// matches the query as a web search, e.g. quoted phrases and -excluded words, against the
+// stemmed words of the name and description. Hits are ranked by ts_rank.
+func (postgresDialect) search(tx *gorm.DB, query string, workspaceId string, limit int) *gorm.DB {
+	return tx.Raw(fmt.Sprintf(`
+			SELECT e.*, ts_rank(%[1]s, q) AS rank
+			FROM entities e, websearch_to_tsquery('english', ?) q
+			WHERE %[1]s @@ q AND e.workspace_id = ? AND e.deleted_at IS NULL
+			ORDER BY rank DESC, e.id
+			LIMIT ?`,
+		searchDocument,
+	),
+		query,
+		workspaceId,
+		limit,
+	)
+}
+
+func (postgresDialect) numberAttribute(key string) (string, []any) {
+	// The CASE keeps the cast away from values that are not numbers
+	return "CASE WHEN jsonb_typeof(entities.attributes -> (?::text)) = 'number' " +
+			"THEN (entities.attributes ->> (?::text))::numeric END",
+		[]any{key, key}
+}
+
+func (postgresDialect) textAttribute(key string, boolean bool) (string, []any) {
+	return "entities.attributes ->> (?::text)", []any{key}
+}
+
+func (postgresDialect) lock(tx *gorm.DB, key int64) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(?)", key).Error
+}
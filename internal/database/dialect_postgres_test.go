@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSearchIndexMatchesSearchDocument(t *testing.T) {
+	if unaliased := strings.ReplaceAll(searchDocument, "e.", ""); unaliased != searchIndexDocument {
+		t.Errorf("the index is built on %s, searches use %s", searchIndexDocument, unaliased)
+	}
+
+	migrations, err := loadMigrations(dialectPostgres)
+	if err != nil {
+		t.Fatalf("unable to load migrations: %v", err)
+	}
+	if !strings.Contains(migrations[0].up, searchIndexDocument) {
+		t.Errorf("the baseline migration does not index %s", searchIndexDocument)
+	}
+}
+
+// TestPostgresSearchIndexDdl runs against the database in TEST_POSTGRES_DSN, on a temporary table
+func TestPostgresSearchIndexDdl(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("unable to connect: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+
+	tx := db.WithContext(context.Background()).Begin()
+	defer tx.Rollback()
+
+	// Shadows the real table for the rest of the transaction
+	if err := tx.Exec("CREATE TEMPORARY TABLE entities (name text, description text) ON COMMIT DROP").Error; err != nil {
+		t.Fatalf("unable to create table: %v", err)
+	}
+	if err := tx.Exec(searchIndexDdl).Error; err != nil {
+		t.Errorf("unable to create the search index: %v", err)
+	}
+}
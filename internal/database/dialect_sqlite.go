@@ -0,0 +1,81 @@
+package database
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"strings"
+)
+
+// sqliteDialect uses the JSON functions of SQLite, it has no full-text search without FTS5
+type sqliteDialect struct{}
+
+func (sqliteDialect) name() string {
+	return dialectSqlite
+}
+
+// search
+// matches every word of the query as a case-insensitive substring of the name or description
+// of the entities, ranking matches in the name above ones in the description. Unlike the search
+// on Postgres words are not stemmed and quotes, "or" and -excluded words have no meaning.
+// The words are matched literally, % and _ in them are escaped.
+func (sqliteDialect) search(tx *gorm.DB, query string, workspaceId string, limit int) *gorm.DB {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		// Like an empty tsquery, an empty query matches nothing
+		return tx.Raw("SELECT e.*, 0 AS rank FROM entities e WHERE 1 = 0")
+	}
+
+	ranks := make([]string, 0, len(words))
+	conditions := make([]string, 0, len(words))
+	var rankArgs, conditionArgs []any
+
+	escape := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	for _, word := range words {
+		pattern := "%" + escape.Replace(word) + "%"
+		ranks = append(ranks, `CASE WHEN e.name LIKE ? ESCAPE '\' THEN 2 ELSE 0 END + `+
+			`CASE WHEN coalesce(e.description, '') LIKE ? ESCAPE '\' THEN 1 ELSE 0 END`)
+		conditions = append(conditions, `(e.name LIKE ? ESCAPE '\' OR coalesce(e.description, '') LIKE ? ESCAPE '\')`)
+		rankArgs = append(rankArgs, pattern, pattern)
+		conditionArgs = append(conditionArgs, pattern, pattern)
+	}
+
+	args := append(rankArgs, conditionArgs...)
+	args = append(args, workspaceId, limit)
+
+	return tx.Raw(fmt.Sprintf(`
+			SELECT e.*, %s AS rank
+			FROM entities e
+			WHERE %s AND e.workspace_id = ? AND e.deleted_at IS NULL
+			ORDER BY rank DESC, e.id
+			LIMIT ?`,
+		strings.Join(ranks, " + "),
+		strings.Join(conditions, " AND "),
+	), args...)
+}
+
+func (sqliteDialect) numberAttribute(key string) (string, []any) {
+	path := sqliteJsonPath(key)
+	return "CASE WHEN json_type(entities.attributes, ?) IN ('integer', 'real') " +
+			"THEN json_extract(entities.attributes, ?) END",
+		[]any{path, path}
+}
+
+func (sqliteDialect) textAttribute(key string, boolean bool) (string, []any) {
+	// json_extract turns booleans into 1 and 0, json_type names them true and false like ->> does
+	function := "json_extract"
+	if boolean {
+		function = "json_type"
+	}
+	return function + "(entities.attributes, ?)", []any{sqliteJsonPath(key)}
+}
+
+func (sqliteDialect) lock(tx *gorm.DB, key int64) error {
+	// Transactions take the write lock up front, see CreateGormSqliteAdapter
+	return nil
+}
+
+// sqliteJsonPath
+// quotes the key as a JSON path, keys may contain dots. Keys are validated and never contain quotes.
+func sqliteJsonPath(key string) string {
+	return `$."` + key + `"`
+}
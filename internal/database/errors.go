@@ -0,0 +1,42 @@
+package database
+
+import "errors"
+
+var (
+	ErrEntityNotFound    = errors.New("entity not found")
+	ErrEntityExists      = errors.New("entity id already in use")
+	ErrParentNotFound    = errors.New("parent entity not found")
+	ErrMoveCycle         = errors.New("entity cannot be moved into itself or its descendants")
+	ErrParentDeleted     = errors.New("parent entity is deleted")
+	ErrInvalidCursor     = errors.New("invalid pagination cursor")
+	ErrTagNotFound       = errors.New("tag not found")
+	ErrTagExists         = errors.New("tag name already in use")
+	ErrTagCreationDenied = errors.New("creating tags requires the editor role in the workspace")
+	ErrCodeInUse         = errors.New("code already assigned to another entity")
+
+	ErrAttributeNotFound         = errors.New("attribute definition not found")
+	ErrInvalidAttributeCondition = errors.New("invalid attribute condition")
+
+	ErrInsufficientQuantity = errors.New("quantity cannot drop below zero")
+	ErrNoHistory            = errors.New("no history recorded for that time")
+
+	ErrAlreadyCheckedOut = errors.New("entity, a container holding it or an entity inside it is checked out")
+	ErrNotCheckedOut     = errors.New("entity is not checked out")
+
+	ErrUserExists         = errors.New("username already taken")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+
+	ErrNoWorkspace       = errors.New("no workspace selected")
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrMemberExists      = errors.New("user is already a member of the workspace")
+	ErrLastOwner         = errors.New("a workspace needs at least one owner")
+	ErrGrantNotFound     = errors.New("grant not found")
+	ErrApiKeyNotFound    = errors.New("api key not found")
+
+	ErrSchemaTooNew       = errors.New("the database schema is newer than this binary")
+	ErrNoMigrationApplied = errors.New("no migration has been applied")
+	ErrNoMigrationsTable  = errors.New("no migrations table, the database has never been migrated")
+	ErrBaselineRollback   = errors.New("rolling back the baseline migration drops every table")
+)
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"Backend/internal/models"
+	"fmt"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+)
+
+// EntityFilter
+// narrows QueryFiltered down, unset fields do not filter.
+type EntityFilter struct {
+	Ids          []string
+	Tags         []string // Tag names
+	MatchAllTags bool     // Entities need every tag instead of any of them
+	Attributes   []*AttributeCondition
+}
+
+func (f *EntityFilter) IsEmpty() bool {
+	return len(f.Ids) == 0 && len(f.Tags) == 0 && len(f.Attributes) == 0
+}
+
+// AttributeCondition compares the attribute Key of an entity with Value
+type AttributeCondition struct {
+	Key      string
+	Operator string
+	Value    string
+}
+
+// attributeOperators are matched in order, two character operators come first
+var attributeOperators = []string{">=", "<=", "!=", "=", "<", ">"}
+
+// ParseAttributeCondition
+// reads a condition written as <key><operator><value>, e.g. price>100 or color=red.
+func ParseAttributeCondition(s string) (*AttributeCondition, error) {
+	i := strings.IndexAny(s, "=<>!")
+	if i <= 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAttributeCondition, s)
+	}
+
+	key, rest := s[:i], s[i:]
+	if err := models.ValidateAttributeKey(key); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAttributeCondition, err)
+	}
+
+	for _, op := range attributeOperators {
+		if strings.HasPrefix(rest, op) {
+			return &AttributeCondition{
+				Key:      key,
+				Operator: op,
+				Value:    strings.TrimPrefix(rest, op),
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrInvalidAttributeCondition, s)
+}
+
+// attributeExpr
+// returns the SQL condition and its arguments. The comparison follows the type of the key
+// in the schema, keys without a definition compare as numbers when the value is numeric.
+func attributeExpr(dialect dialect, cond *AttributeCondition, def *models.AttributeDefinition) (string, []any, error) {
+	attrType := models.AttributeTypeString
+	switch {
+	case def != nil:
+		attrType = def.Type
+	case cond.Value == "true" || cond.Value == "false":
+		attrType = models.AttributeTypeBool
+	default:
+		if _, err := strconv.ParseFloat(cond.Value, 64); err == nil {
+			attrType = models.AttributeTypeNumber
+		}
+	}
+
+	switch attrType {
+	case models.AttributeTypeNumber:
+		value, err := strconv.ParseFloat(cond.Value, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %s compares as a number", ErrInvalidAttributeCondition, cond.Key)
+		}
+		expr, args := dialect.numberAttribute(cond.Key)
+		return fmt.Sprintf("%s %s ?", expr, cond.Operator), append(args, value), nil
+	case models.AttributeTypeBool:
+		if cond.Operator != "=" && cond.Operator != "!=" {
+			return "", nil, fmt.Errorf("%w: %s only supports = and !=", ErrInvalidAttributeCondition, cond.Key)
+		}
+		if _, err := strconv.ParseBool(cond.Value); err != nil {
+			return "", nil, fmt.Errorf("%w: %s compares as a boolean", ErrInvalidAttributeCondition, cond.Key)
+		}
+	case models.AttributeTypeDate:
+		normalized, err := def.Normalize(cond.Value)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %v", ErrInvalidAttributeCondition, err)
+		}
+		cond = &AttributeCondition{Key: cond.Key, Operator: cond.Operator, Value: normalized.(string)}
+	}
+
+	// Strings, enums, booleans and dates, stored dates sort correctly as text
+	operator := cond.Operator
+	if operator == "!=" {
+		operator = "<>"
+	}
+	expr, args := dialect.textAttribute(cond.Key, attrType == models.AttributeTypeBool)
+	return fmt.Sprintf("%s %s ?", expr, operator), append(args, cond.Value), nil
+}
+
+func applyFilter(tx *gorm.DB, filter EntityFilter, defs map[string]*models.AttributeDefinition) (*gorm.DB, error) {
+	if len(filter.Ids) > 0 {
+		tx = tx.Where("entities.id IN ?", filter.Ids)
+	}
+
+	if len(filter.Tags) > 0 {
+		tagged := tx.
+			Session(&gorm.Session{NewDB: true}).
+			Table("entity_tags").
+			Select("entity_tags.entity_id").
+			Joins("JOIN tags ON tags.id = entity_tags.tag_id").
+			Where("tags.name IN ?", filter.Tags)
+
+		if filter.MatchAllTags {
+			tagged = tagged.
+				Group("entity_tags.entity_id").
+				Having("COUNT(DISTINCT tags.id) = ?", len(filter.Tags))
+		}
+
+		tx = tx.Where("entities.id IN (?)", tagged)
+	}
+
+	for _, cond := range filter.Attributes {
+		expr, args, err := attributeExpr(dialectOf(tx), cond, defs[cond.Key])
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where(expr, args...)
+	}
+
+	return tx, nil
+}
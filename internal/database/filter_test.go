@@ -0,0 +1,49 @@
+package database
+
+import (
+	"Backend/internal/models"
+	"errors"
+	"testing"
+)
+
+func TestParseAttributeCondition(t *testing.T) {
+	cases := map[string]AttributeCondition{
+		"price>=100":  {Key: "price", Operator: ">=", Value: "100"},
+		"color=red":   {Key: "color", Operator: "=", Value: "red"},
+		"color!=blue": {Key: "color", Operator: "!=", Value: "blue"},
+		"note=a=b":    {Key: "note", Operator: "=", Value: "a=b"},
+	}
+
+	for raw, expected := range cases {
+		cond, err := ParseAttributeCondition(raw)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", raw, err)
+			continue
+		}
+		if *cond != expected {
+			t.Errorf("%q: expected %+v, got %+v", raw, expected, *cond)
+		}
+	}
+}
+
+func TestParseAttributeConditionInvalid(t *testing.T) {
+	for _, raw := range []string{"", "price", "=100", "price!100"} {
+		if _, err := ParseAttributeCondition(raw); !errors.Is(err, ErrInvalidAttributeCondition) {
+			t.Errorf("%q: expected ErrInvalidAttributeCondition, got %v", raw, err)
+		}
+	}
+}
+
+func TestAttributeExprFollowsSchema(t *testing.T) {
+	cond := &AttributeCondition{Key: "size", Operator: ">", Value: "abc"}
+	number := &models.AttributeDefinition{Key: "size", Type: models.AttributeTypeNumber}
+
+	if _, _, err := attributeExpr(postgresDialect{}, cond, number); !errors.Is(err, ErrInvalidAttributeCondition) {
+		t.Errorf("expected a non numeric value to be rejected, got %v", err)
+	}
+
+	flag := &AttributeCondition{Key: "broken", Operator: ">", Value: "true"}
+	if _, _, err := attributeExpr(postgresDialect{}, flag, nil); !errors.Is(err, ErrInvalidAttributeCondition) {
+		t.Errorf("expected > on a boolean to be rejected, got %v", err)
+	}
+}
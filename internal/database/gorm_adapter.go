@@ -0,0 +1,1141 @@
+package database
+
+import (
+	"Backend/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// GormAdapter implements Repository with gorm, on Postgres or on SQLite. The queries are
+// shared, the SQL that differs between the databases comes from the dialect of the
+// connection, see dialect. The constructors are CreateGormPgAdapter and CreateGormSqliteAdapter.
+type GormAdapter struct {
+	open   func() gorm.Dialector
+	config *gorm.Config
+
+	db *gorm.DB
+}
+
+func (g *GormAdapter) ensureDbConnection(ctx context.Context) error {
+
+	if g.db == nil {
+		if conErr := g.Connect(ctx); conErr != nil {
+			return conErr
+		}
+	}
+
+	return nil
+}
+
+func (g *GormAdapter) Connect(ctx context.Context) error {
+	config := g.config
+	if config == nil {
+		config = &gorm.Config{TranslateError: true}
+	}
+
+	db, err := gorm.Open(g.open(), config)
+	if err != nil {
+		return err
+	}
+	g.db = db
+	return nil
+}
+
+func (g *GormAdapter) Disconnect(ctx context.Context) error {
+	if g.db == nil {
+		return nil // Nothing to disconnect
+	}
+
+	// Get the underlying SQL DB
+	sqlDB, err := g.db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get underlying SQL DB: %w", err)
+	}
+
+	// Close the database connection
+	if err := sqlDB.Close(); err != nil {
+		return fmt.Errorf("failed to close the database connection: %w", err)
+	}
+
+	g.db = nil // Set to nil to indicate the connection is closed
+	return nil
+}
+
+////////////////////////////////////////////////
+// DB Methods
+////////////////////////////////////////////////
+
+// CreateEntity
+// this method stores the entity in the workspace of the context. The parent has to be
+// a live entity of the same workspace. Tags are given by name, missing ones are created
+// unless the context is WithoutTagCreation. Ids are unique across workspaces, a taken one
+// fails with ErrEntityExists whichever workspace holds it.
+func (g *GormAdapter) CreateEntity(ctx context.Context, e *models.Entity) error {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return err
+	}
+	e.WorkspaceId = workspaceId
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if e.ParentId != nil {
+			var count int64
+			if err := tx.
+				Model(&models.Entity{}).
+				Scopes(inWorkspace(workspaceId)).
+				Where("id = ?", *e.ParentId).
+				Count(&count).
+				Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return ErrParentNotFound
+			}
+		}
+
+		// Tags are given by name, swap them for the stored ones
+		if len(e.Tags) > 0 {
+			names := make([]string, 0, len(e.Tags))
+			for _, t := range e.Tags {
+				names = append(names, t.Name)
+			}
+			tags, err := resolveTags(tx, workspaceId, names, tagCreationAllowed(ctx))
+			if err != nil {
+				return err
+			}
+			e.Tags = tags
+		}
+
+		if e.Code != nil {
+			if err := ensureCodeFree(tx, workspaceId, *e.Code, e.Id); err != nil {
+				return err
+			}
+		}
+
+		if err := normalizeEntityAttributes(tx, e); err != nil {
+			return err
+		}
+
+		// gorm leaves zero values to the column default, which is 1 for the quantity
+		quantity := e.Quantity
+
+		if res := tx.Create(e); res.Error != nil {
+			return res.Error
+		}
+
+		if quantity == 0 {
+			if err := tx.Model(e).UpdateColumn("quantity", 0).Error; err != nil {
+				return err
+			}
+			e.Quantity = 0
+		}
+
+		after, err := snapshotOf(tx, e.Id)
+		if err != nil {
+			return err
+		}
+		return recordEvent(tx, models.EntityActionCreate, nil, after)
+	}); err != nil {
+		return duplicateAs(err, func() error {
+			if e.Code != nil {
+				if err := ensureCodeFree(g.db.WithContext(ctx), workspaceId, *e.Code, e.Id); err != nil {
+					return err
+				}
+			}
+			return ensureIdFree(g.db.WithContext(ctx), e.Id)
+		})
+	}
+
+	return nil
+}
+
+// QueryTopLevel
+// this method is to get a page of top level entities with its direct children populated.
+func (g *GormAdapter) QueryTopLevel(ctx context.Context, page PageRequest) (*Page, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := paginate(g.db.WithContext(ctx).Scopes(inWorkspace(workspaceId)), page)
+	if err != nil {
+		return nil, err
+	}
+
+	var entities []*models.Entity
+	if err := tx.
+		Where("parent_id IS NULL").
+		Preload("Children").
+		Preload("Tags").
+		Find(&entities).
+		Error; err != nil {
+		return nil, err
+	}
+
+	return newPage(entities, page), nil
+}
+
+func (g *GormAdapter) QueryById(ctx context.Context, id string) (*models.Entity, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var entities models.Entity
+
+	if err := g.db.
+		WithContext(ctx).
+		Scopes(inWorkspace(workspaceId)).
+		Preload("Children").
+		Preload("Tags").
+		First(&entities, "id = ?", id).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEntityNotFound
+		}
+		return nil, err
+	}
+
+	return &entities, nil
+}
+
+// QueryByCode
+// this method resolves the code printed on a label to its entity.
+func (g *GormAdapter) QueryByCode(ctx context.Context, code string) (*models.Entity, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var entity models.Entity
+
+	if err := g.db.
+		WithContext(ctx).
+		Scopes(inWorkspace(workspaceId)).
+		Preload("Children").
+		Preload("Tags").
+		First(&entity, "code = ?", code).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEntityNotFound
+		}
+		return nil, err
+	}
+
+	return &entity, nil
+}
+
+func (g *GormAdapter) QueryMultipleById(ctx context.Context, page PageRequest, ids ...string) (*Page, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := paginate(g.db.WithContext(ctx).Scopes(inWorkspace(workspaceId)), page)
+	if err != nil {
+		return nil, err
+	}
+
+	var entities []*models.Entity
+
+	if err := tx.
+		Preload("Children").
+		Preload("Tags").
+		Where("id IN ?", ids).
+		Find(&entities).
+		Error; err != nil {
+		return nil, err
+	}
+
+	return newPage(entities, page), nil
+
+}
+
+// QueryFiltered
+// this method is to get a page of entities on any level that match the filter,
+// with their direct children populated.
+func (g *GormAdapter) QueryFiltered(ctx context.Context, page PageRequest, filter EntityFilter) (*Page, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := paginate(g.db.WithContext(ctx).Scopes(inWorkspace(workspaceId)), page)
+	if err != nil {
+		return nil, err
+	}
+
+	// Conditions on attributes compare according to the type of the key
+	defs := make(map[string]*models.AttributeDefinition)
+	if len(filter.Attributes) > 0 {
+		if defs, err = attributeSchema(g.db.WithContext(ctx), workspaceId); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err = applyFilter(tx, filter, defs)
+	if err != nil {
+		return nil, err
+	}
+
+	var entities []*models.Entity
+	if err := tx.
+		Preload("Children").
+		Preload("Tags").
+		Find(&entities).
+		Error; err != nil {
+		return nil, err
+	}
+
+	return newPage(entities, page), nil
+}
+
+// QueryAncestors
+// this method returns the ancestors of the entity ordered from the top level down,
+// the entity itself is not included.
+func (g *GormAdapter) QueryAncestors(ctx context.Context, id string) ([]*models.Entity, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ensureInWorkspace(g.db.WithContext(ctx), workspaceId, id); err != nil {
+		return nil, err
+	}
+
+	ids, err := ancestorIds(g.db.WithContext(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, ErrEntityNotFound
+	}
+
+	var entities []*models.Entity
+	if err := g.db.
+		WithContext(ctx).
+		Where("id IN ?", ids[1:]).
+		Find(&entities).
+		Error; err != nil {
+		return nil, err
+	}
+
+	byId := make(map[string]*models.Entity, len(entities))
+	for _, e := range entities {
+		byId[e.Id] = e
+	}
+
+	ancestors := make([]*models.Entity, 0, len(entities))
+	for i := len(ids) - 1; i > 0; i-- {
+		if e, ok := byId[ids[i]]; ok {
+			ancestors = append(ancestors, e)
+		}
+	}
+
+	return ancestors, nil
+}
+
+// QuerySubtree
+// this method returns the entity with its descendants nested under Children, down to
+// maxDepth levels below it, using a single recursive query. At most maxNodes entities
+// are loaded. The returned flag reports whether the tree was cut short, because of
+// maxNodes or because there are entities below maxDepth.
+// Entities on the last loaded level keep a nil Children, as theirs were not loaded.
+func (g *GormAdapter) QuerySubtree(
+	ctx context.Context,
+	id string,
+	maxDepth int,
+	maxNodes int,
+) (*models.Entity, bool, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, false, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var rows []*subtreeRow
+	if err := g.db.
+		WithContext(ctx).
+		Raw(`
+			WITH RECURSIVE subtree AS (
+				SELECT e.*, 0 AS depth
+				FROM entities e
+				WHERE e.id = ? AND e.workspace_id = ? AND e.deleted_at IS NULL
+				UNION ALL
+				SELECT e.*, s.depth + 1
+				FROM entities e
+				JOIN subtree s ON e.parent_id = s.id
+				WHERE e.deleted_at IS NULL AND s.depth < ?
+			)
+			SELECT * FROM subtree ORDER BY depth, id LIMIT ?`,
+			id,
+			workspaceId,
+			maxDepth+1, // One level more tells whether maxDepth cut anything off
+			maxNodes+1,
+		).
+		Scan(&rows).
+		Error; err != nil {
+		return nil, false, err
+	}
+
+	if len(rows) == 0 {
+		return nil, false, ErrEntityNotFound
+	}
+
+	truncated := len(rows) > maxNodes || rows[len(rows)-1].Depth > maxDepth
+	for len(rows) > 0 && rows[len(rows)-1].Depth > maxDepth {
+		rows = rows[:len(rows)-1]
+	}
+	if len(rows) > maxNodes {
+		rows = rows[:maxNodes]
+	}
+
+	byId := make(map[string]*models.Entity, len(rows))
+	for _, row := range rows {
+		if row.Depth < maxDepth {
+			row.Entity.Children = make([]*models.Entity, 0)
+		}
+		byId[row.Entity.Id] = &row.Entity
+	}
+
+	// Rows are ordered by depth, so every parent is in place before its children
+	for _, row := range rows[1:] {
+		if row.Entity.ParentId == nil {
+			continue
+		}
+		if parent, ok := byId[*row.Entity.ParentId]; ok {
+			parent.Children = append(parent.Children, &row.Entity)
+		}
+	}
+
+	return &rows[0].Entity, truncated, nil
+}
+
+// SearchEntities
+// this method runs a full-text search over the name and description of the entities.
+// Hits are ranked best first and carry their ancestors from the top level down.
+// Without Postgres text search, every word of the query has to appear in either field.
+func (g *GormAdapter) SearchEntities(ctx context.Context, query string, limit int) ([]*models.SearchHit, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := dialectOf(g.db).search(g.db.WithContext(ctx), query, workspaceId, limit)
+
+	var rows []*searchRow
+	if err := raw.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.Entity.Id)
+	}
+
+	ancestors, err := ancestorsOf(g.db.WithContext(ctx), ids)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]*models.SearchHit, 0, len(rows))
+	for _, row := range rows {
+		hit := &models.SearchHit{
+			Entity:    &row.Entity,
+			Rank:      row.Rank,
+			Ancestors: ancestors[row.Entity.Id],
+		}
+		if hit.Ancestors == nil {
+			hit.Ancestors = make([]*models.Entity, 0)
+		}
+		hits = append(hits, hit)
+	}
+
+	return hits, nil
+}
+
+// UpdateEntity
+// this method loads the entity, applies the given options and persists the result.
+// Fields without a matching option are left untouched.
+func (g *GormAdapter) UpdateEntity(
+	ctx context.Context,
+	id string,
+	opts ...models.NewEntityOption,
+) (*models.Entity, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var entity models.Entity
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scopes(inWorkspace(workspaceId)).
+			First(&entity, "id = ?", id).
+			Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEntityNotFound
+			}
+			return err
+		}
+
+		return recordChange(tx, id, models.EntityActionUpdate, func() error {
+			previousCode := entity.Code
+
+			for _, opt := range opts {
+				opt(&entity)
+			}
+
+			// An empty code keeps the current one instead of clearing it
+			if entity.Code == nil {
+				entity.Code = previousCode
+			}
+			if entity.Code != nil && (previousCode == nil || *entity.Code != *previousCode) {
+				if err := ensureCodeFree(tx, entity.WorkspaceId, *entity.Code, entity.Id); err != nil {
+					return err
+				}
+			}
+
+			if err := normalizeEntityAttributes(tx, &entity); err != nil {
+				return err
+			}
+
+			return tx.
+				Omit(clause.Associations).
+				Save(&entity).
+				Error
+		})
+	}); err != nil {
+		return nil, duplicateAs(err, func() error {
+			if entity.Code == nil {
+				return nil
+			}
+			return ensureCodeFree(g.db.WithContext(ctx), workspaceId, *entity.Code, id)
+		})
+	}
+
+	return g.QueryById(ctx, id)
+}
+
+// DeleteEntity
+// this method soft deletes the entity. Entities with children are refused by
+// models.Entity.BeforeDelete unless cascade is set, in which case the whole subtree
+// is deleted deepest first so that every delete still passes the child guard. The
+// subtree shares one deletion time, which is how RestoreEntity tells it apart.
+func (g *GormAdapter) DeleteEntity(ctx context.Context, id string, cascade bool) error {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return err
+	}
+
+	return g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var entity models.Entity
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scopes(inWorkspace(workspaceId)).
+			First(&entity, "id = ?", id).
+			Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEntityNotFound
+			}
+			return err
+		}
+
+		if !cascade {
+			return recordChange(tx, id, models.EntityActionDelete, func() error {
+				return tx.Delete(&entity).Error
+			})
+		}
+
+		ids, err := subtreeIds(tx, id, false)
+		if err != nil {
+			return err
+		}
+
+		deletedAt := tx.NowFunc()
+		deleting := tx.Session(&gorm.Session{NowFunc: func() time.Time { return deletedAt }})
+		for _, subId := range ids {
+			if err := recordChange(tx, subId, models.EntityActionDelete, func() error {
+				return deleting.Delete(&models.Entity{Id: subId}).Error
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// MoveEntity
+// this method re-parents the entity, a nil parentId moves it to the top level.
+// Moves are serialised with an advisory lock so that two concurrent moves cannot
+// form a cycle that neither of them could see on its own. The parent has to be in
+// the same workspace.
+func (g *GormAdapter) MoveEntity(ctx context.Context, id string, parentId *string) (*models.Entity, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := dialectOf(tx).lock(tx, moveLockKey); err != nil {
+			return err
+		}
+
+		var entity models.Entity
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scopes(inWorkspace(workspaceId)).
+			First(&entity, "id = ?", id).
+			Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEntityNotFound
+			}
+			return err
+		}
+
+		if parentId != nil {
+			var parent models.Entity
+			if err := tx.
+				Scopes(inWorkspace(workspaceId)).
+				First(&parent, "id = ?", *parentId).
+				Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrParentNotFound
+				}
+				return err
+			}
+
+			ids, err := ancestorIds(tx, parent.Id)
+			if err != nil {
+				return err
+			}
+			for _, ancestorId := range ids {
+				if ancestorId == entity.Id {
+					return ErrMoveCycle
+				}
+			}
+		}
+
+		return recordChange(tx, id, models.EntityActionMove, func() error {
+			return tx.
+				Model(&entity).
+				Update("parent_id", parentId).
+				Error
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	return g.QueryById(ctx, id)
+}
+
+// QueryDeleted
+// this method lists the soft-deleted entities, most recently deleted first.
+func (g *GormAdapter) QueryDeleted(ctx context.Context) ([]*models.Entity, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var entities []*models.Entity
+	if err := g.db.
+		WithContext(ctx).
+		Unscoped().
+		Scopes(inWorkspace(workspaceId)).
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&entities).
+		Error; err != nil {
+		return nil, err
+	}
+
+	return entities, nil
+}
+
+// RestoreEntity
+// this method brings a soft-deleted entity back, with cascade set the descendants
+// deleted together with it are restored as well. Descendants deleted on their own
+// before stay in the trash. The parent has to be live, otherwise the restored entity
+// would be hidden under a deleted one.
+func (g *GormAdapter) RestoreEntity(ctx context.Context, id string, cascade bool) (*models.Entity, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var entity models.Entity
+		if err := tx.
+			Unscoped().
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scopes(inWorkspace(workspaceId)).
+			Where("deleted_at IS NOT NULL").
+			First(&entity, "id = ?", id).
+			Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEntityNotFound
+			}
+			return err
+		}
+
+		if entity.ParentId != nil {
+			var count int64
+			if err := tx.
+				Model(&models.Entity{}).
+				Where("id = ?", *entity.ParentId).
+				Count(&count).
+				Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return ErrParentDeleted
+			}
+		}
+
+		ids := []string{id}
+		if cascade {
+			subIds, err := deletedWith(tx, id, entity.DeletedAt.Time)
+			if err != nil {
+				return err
+			}
+			ids = subIds
+		}
+
+		if err := tx.
+			Unscoped().
+			Model(&models.Entity{}).
+			Where("id IN ?", ids).
+			Update("deleted_at", nil).
+			Error; err != nil {
+			return err
+		}
+
+		for _, restoredId := range ids {
+			after, err := snapshotOf(tx, restoredId)
+			if err != nil {
+				return err
+			}
+			if err := recordEvent(tx, models.EntityActionRestore, nil, after); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return g.QueryById(ctx, id)
+}
+
+// PurgeDeleted
+// this method permanently removes entities that were soft-deleted before the given time
+// and returns them, so that their images can be removed as well. An entity with a descendant
+// that is not due yet, live or deleted later, is kept together with the entities between them.
+// Their history stays.
+func (g *GormAdapter) PurgeDeleted(ctx context.Context, deletedBefore time.Time) ([]*models.Entity, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	purged := make([]*models.Entity, 0)
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Walks up the lineage of every entity that is not due, its ancestors have to stay
+		if err := tx.
+			Raw(`
+				WITH RECURSIVE kept AS (
+					SELECT parent_id AS id
+					FROM entities
+					WHERE workspace_id = ? AND parent_id IS NOT NULL
+						AND (deleted_at IS NULL OR deleted_at >= ?)
+					UNION
+					SELECT e.parent_id
+					FROM entities e
+					JOIN kept k ON e.id = k.id
+					WHERE e.parent_id IS NOT NULL
+				)
+				SELECT * FROM entities
+				WHERE workspace_id = ? AND deleted_at IS NOT NULL AND deleted_at < ?
+					AND id NOT IN (SELECT id FROM kept)`,
+				workspaceId, deletedBefore, workspaceId, deletedBefore,
+			).
+			Scan(&purged).
+			Error; err != nil {
+			return err
+		}
+		if len(purged) == 0 {
+			return nil
+		}
+
+		ids := make([]string, 0, len(purged))
+		for _, e := range purged {
+			ids = append(ids, e.Id)
+		}
+
+		for _, model := range []any{&models.StockAdjustment{}, &models.Loan{}, &models.SubtreeGrant{}} {
+			if err := tx.
+				Where("entity_id IN ?", ids).
+				Delete(model).
+				Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.
+			Table("entity_tags").
+			Where("entity_id IN ?", ids).
+			Delete(nil).
+			Error; err != nil {
+			return err
+		}
+
+		// The purged entities hold no children but each other, which go in the same statement.
+		// The child guard of models.Entity.BeforeDelete would check them one by one.
+		return tx.
+			Session(&gorm.Session{SkipHooks: true}).
+			Unscoped().
+			Where("id IN ?", ids).
+			Delete(&models.Entity{}).
+			Error
+	}); err != nil {
+		return nil, err
+	}
+
+	return purged, nil
+}
+
+// QueryImageUrls
+// this method lists the images of every entity in every workspace, trashed ones included,
+// for jobs that maintain the object store.
+func (g *GormAdapter) QueryImageUrls(ctx context.Context) ([]string, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	var lists []models.StringList
+	if err := g.db.
+		WithContext(ctx).
+		Unscoped().
+		Model(&models.Entity{}).
+		Pluck("images", &lists).
+		Error; err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0, len(lists))
+	for _, l := range lists {
+		urls = append(urls, l...)
+	}
+
+	return urls, nil
+}
+
+////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////
+
+// ensureInWorkspace
+// fails with ErrEntityNotFound unless the entity is a live entity of the workspace.
+func ensureInWorkspace(tx *gorm.DB, workspaceId string, id string) error {
+	var count int64
+	if err := tx.
+		Model(&models.Entity{}).
+		Scopes(inWorkspace(workspaceId)).
+		Where("id = ?", id).
+		Count(&count).
+		Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrEntityNotFound
+	}
+	return nil
+}
+
+// ensureCodeFree
+// fails with ErrCodeInUse when an entity of the workspace other than exceptId, deleted ones
+// included, holds the code.
+func ensureCodeFree(tx *gorm.DB, workspaceId string, code string, exceptId string) error {
+	var count int64
+	if err := tx.
+		Unscoped().
+		Model(&models.Entity{}).
+		Scopes(inWorkspace(workspaceId)).
+		Where("code = ? AND id <> ?", code, exceptId).
+		Count(&count).
+		Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCodeInUse
+	}
+	return nil
+}
+
+// ensureIdFree
+// fails with ErrEntityExists when any entity, deleted ones and those of other workspaces
+// included, has the id.
+func ensureIdFree(tx *gorm.DB, id string) error {
+	var count int64
+	if err := tx.
+		Unscoped().
+		Model(&models.Entity{}).
+		Where("id = ?", id).
+		Count(&count).
+		Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrEntityExists
+	}
+	return nil
+}
+
+// subtreeRow is an entity row of QuerySubtree together with its depth below the root
+type subtreeRow struct {
+	models.Entity
+	Depth int
+}
+
+// searchRow is an entity row of SearchEntities together with its rank
+type searchRow struct {
+	models.Entity
+	Rank float64
+}
+
+// moveLockKey identifies the advisory lock taken by MoveEntity
+const moveLockKey int64 = 0x74746d76
+
+// ancestorIds
+// returns the id of the entity followed by the ids of its ancestors, nearest first.
+func ancestorIds(tx *gorm.DB, id string) ([]string, error) {
+	var ids []string
+
+	if err := tx.
+		Raw(`
+			WITH RECURSIVE ancestors AS (
+				SELECT id, parent_id, 0 AS depth
+				FROM entities
+				WHERE id = ? AND deleted_at IS NULL
+				UNION ALL
+				SELECT e.id, e.parent_id, a.depth + 1
+				FROM entities e
+				JOIN ancestors a ON e.id = a.parent_id
+				WHERE e.deleted_at IS NULL
+			)
+			SELECT id FROM ancestors ORDER BY depth`,
+			id,
+		).
+		Scan(&ids).
+		Error; err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// ancestorsOf
+// returns the ancestors of each of the given entities ordered from the top level down,
+// keyed by the entity id. All chains are resolved with one recursive query.
+func ancestorsOf(tx *gorm.DB, ids []string) (map[string][]*models.Entity, error) {
+	res := make(map[string][]*models.Entity, len(ids))
+	if len(ids) == 0 {
+		return res, nil
+	}
+
+	var links []*struct {
+		Origin string
+		Id     string
+		Depth  int
+	}
+	if err := tx.
+		Raw(`
+			WITH RECURSIVE ancestors AS (
+				SELECT e.id AS origin, e.parent_id AS id, 1 AS depth
+				FROM entities e
+				WHERE e.id IN ? AND e.parent_id IS NOT NULL
+				UNION ALL
+				SELECT a.origin, e.parent_id, a.depth + 1
+				FROM entities e
+				JOIN ancestors a ON e.id = a.id
+				WHERE e.deleted_at IS NULL AND e.parent_id IS NOT NULL
+			)
+			SELECT origin, id, depth FROM ancestors ORDER BY origin, depth DESC`,
+			ids,
+		).
+		Scan(&links).
+		Error; err != nil {
+		return nil, err
+	}
+
+	ancestorIds := make([]string, 0, len(links))
+	for _, l := range links {
+		ancestorIds = append(ancestorIds, l.Id)
+	}
+
+	var entities []*models.Entity
+	if err := tx.
+		Where("id IN ?", ancestorIds).
+		Find(&entities).
+		Error; err != nil {
+		return nil, err
+	}
+
+	byId := make(map[string]*models.Entity, len(entities))
+	for _, e := range entities {
+		byId[e.Id] = e
+	}
+
+	for _, l := range links {
+		if e, ok := byId[l.Id]; ok {
+			res[l.Origin] = append(res[l.Origin], e)
+		}
+	}
+
+	return res, nil
+}
+
+// subtreeIds
+// returns the ids of the entity and all of its descendants, deepest first.
+// When deleted is set the walk goes through soft-deleted entities instead of live ones.
+func subtreeIds(tx *gorm.DB, id string, deleted bool) ([]string, error) {
+	condition := "deleted_at IS NULL"
+	if deleted {
+		condition = "deleted_at IS NOT NULL"
+	}
+
+	var ids []string
+
+	if err := tx.
+		Raw(fmt.Sprintf(`
+			WITH RECURSIVE subtree AS (
+				SELECT id, 0 AS depth
+				FROM entities
+				WHERE id = ? AND %[1]s
+				UNION ALL
+				SELECT e.id, s.depth + 1
+				FROM entities e
+				JOIN subtree s ON e.parent_id = s.id
+				WHERE e.%[1]s
+			)
+			SELECT id FROM subtree ORDER BY depth DESC`,
+			condition,
+		),
+			id,
+		).
+		Scan(&ids).
+		Error; err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// deletedWith
+// returns the id of the entity and the ids of its descendants that were deleted at the same
+// time, i.e. by the cascading delete of the entity, deepest first.
+func deletedWith(tx *gorm.DB, id string, deletedAt time.Time) ([]string, error) {
+	var ids []string
+
+	if err := tx.
+		Raw(`
+			WITH RECURSIVE subtree AS (
+				SELECT id, 0 AS depth
+				FROM entities
+				WHERE id = ? AND deleted_at = ?
+				UNION ALL
+				SELECT e.id, s.depth + 1
+				FROM entities e
+				JOIN subtree s ON e.parent_id = s.id
+				WHERE e.deleted_at = ?
+			)
+			SELECT id FROM subtree ORDER BY depth DESC`,
+			id, deletedAt, deletedAt,
+		).
+		Scan(&ids).
+		Error; err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// duplicateAs
+// maps the unique violation of a write that lost the race against a concurrent one to the
+// error of the check that ran before it, e.g. ErrCodeInUse. The check runs again outside the
+// failed transaction and sees the row of the winner now. Other errors are returned as they are.
+func duplicateAs(err error, check func() error) error {
+	if !errors.Is(err, gorm.ErrDuplicatedKey) {
+		return err
+	}
+	if checkErr := check(); checkErr != nil {
+		return checkErr
+	}
+	return err
+}
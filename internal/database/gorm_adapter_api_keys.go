@@ -0,0 +1,143 @@
+package database
+
+import (
+	"Backend/internal/models"
+	"context"
+	"time"
+)
+
+// apiKeyTouchInterval limits how often the last use of a key is written, not every request needs a write
+const apiKeyTouchInterval = time.Minute
+
+////////////////////////////////////////////////
+// API Key Methods
+////////////////////////////////////////////////
+
+// CreateApiKey
+// this method creates a key for the workspace and returns it together with the key itself,
+// which is not stored and cannot be retrieved later.
+func (g *GormAdapter) CreateApiKey(
+	ctx context.Context,
+	name string,
+	scope models.ApiKeyScope,
+	createdBy string,
+	expiresAt *time.Time,
+) (*models.ApiKey, string, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, "", err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key, record, err := models.NewApiKey(workspaceId, name, scope, createdBy, expiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := g.db.
+		WithContext(ctx).
+		Create(record).
+		Error; err != nil {
+		return nil, "", err
+	}
+
+	return record, key, nil
+}
+
+// QueryApiKeys
+// this method lists the keys of the workspace, expired ones included so that they can be cleaned up.
+func (g *GormAdapter) QueryApiKeys(ctx context.Context) ([]*models.ApiKey, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []*models.ApiKey
+	if err := g.db.
+		WithContext(ctx).
+		Scopes(inWorkspace(workspaceId)).
+		Order("created_at").
+		Find(&keys).
+		Error; err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (g *GormAdapter) RevokeApiKey(ctx context.Context, id string) error {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return err
+	}
+
+	res := g.db.
+		WithContext(ctx).
+		Scopes(inWorkspace(workspaceId)).
+		Delete(&models.ApiKey{}, "id = ?", id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrApiKeyNotFound
+	}
+
+	return nil
+}
+
+// QueryApiKeyByToken
+// this method returns the key unless it is unknown or expired, and records that it was used.
+// A key only works while the member who created it is part of its workspace, and its scope
+// is capped at their current role, so that demoting or removing them takes effect on their keys.
+func (g *GormAdapter) QueryApiKeyByToken(ctx context.Context, token string) (*models.ApiKey, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	var row struct {
+		models.ApiKey
+		CreatorRole models.Role
+	}
+	res := g.db.
+		WithContext(ctx).
+		Model(&models.ApiKey{}).
+		Select("api_keys.*, workspace_members.role AS creator_role").
+		Joins("JOIN workspace_members ON workspace_members.workspace_id = api_keys.workspace_id AND workspace_members.user_id = api_keys.created_by").
+		Where("api_keys.key_hash = ? AND (api_keys.expires_at IS NULL OR api_keys.expires_at > ?)", models.HashToken(token), now).
+		Limit(1).
+		Scan(&row)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	key := row.ApiKey
+	key.Scope = key.Scope.CappedAt(row.CreatorRole)
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval {
+		if err := g.db.
+			WithContext(ctx).
+			Model(&models.ApiKey{Id: key.Id}).
+			UpdateColumn("last_used_at", now).
+			Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return &key, nil
+}
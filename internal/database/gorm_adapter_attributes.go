@@ -0,0 +1,126 @@
+package database
+
+import (
+	"Backend/internal/models"
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+////////////////////////////////////////////////
+// Attribute Schema Methods
+////////////////////////////////////////////////
+
+func (g *GormAdapter) QueryAttributeSchema(ctx context.Context) ([]*models.AttributeDefinition, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs []*models.AttributeDefinition
+	if err := g.db.
+		WithContext(ctx).
+		Scopes(inWorkspace(workspaceId)).
+		Order("key").
+		Find(&defs).
+		Error; err != nil {
+		return nil, err
+	}
+
+	return defs, nil
+}
+
+// UpsertAttributeDefinition
+// this method creates or replaces the definition of a key in the workspace. Values stored before
+// the change are not revalidated, they are checked again the next time the entity is updated.
+func (g *GormAdapter) UpsertAttributeDefinition(ctx context.Context, def *models.AttributeDefinition) error {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return err
+	}
+	def.WorkspaceId = workspaceId
+
+	return g.db.
+		WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "workspace_id"}, {Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"type", "options", "updated_at"}),
+		}).
+		Create(def).
+		Error
+}
+
+func (g *GormAdapter) DeleteAttributeDefinition(ctx context.Context, key string) error {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return err
+	}
+
+	res := g.db.
+		WithContext(ctx).
+		Scopes(inWorkspace(workspaceId)).
+		Delete(&models.AttributeDefinition{}, "key = ?", key)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrAttributeNotFound
+	}
+
+	return nil
+}
+
+////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////
+
+func attributeSchema(tx *gorm.DB, workspaceId string) (map[string]*models.AttributeDefinition, error) {
+	var defs []*models.AttributeDefinition
+	if err := tx.
+		Scopes(inWorkspace(workspaceId)).
+		Find(&defs).
+		Error; err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]*models.AttributeDefinition, len(defs))
+	for _, d := range defs {
+		res[d.Key] = d
+	}
+
+	return res, nil
+}
+
+// normalizeEntityAttributes
+// validates the attributes of the entity against the schema of its workspace and stores them in normalized form.
+func normalizeEntityAttributes(tx *gorm.DB, e *models.Entity) error {
+	if len(e.Attributes) == 0 {
+		e.Attributes = models.Attributes{}
+		return nil
+	}
+
+	defs, err := attributeSchema(tx, e.WorkspaceId)
+	if err != nil {
+		return err
+	}
+
+	attrs, err := models.NormalizeAttributes(defs, e.Attributes)
+	if err != nil {
+		return err
+	}
+	e.Attributes = attrs
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"Backend/internal/models"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"reflect"
+	"time"
+)
+
+// maxLocationDepth bounds the walk up the historic ancestors in QueryLocationAt
+const maxLocationDepth = 64
+
+////////////////////////////////////////////////
+// History Methods
+////////////////////////////////////////////////
+
+// QueryHistory
+// this method returns the events of an entity newest first. A non-zero before only
+// returns older events, so that the previous page starts at the last created_at seen.
+// Deleted and purged entities keep their history.
+func (g *GormAdapter) QueryHistory(
+	ctx context.Context,
+	id string,
+	limit int,
+	before time.Time,
+) ([]*models.EntityEvent, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := g.db.
+		WithContext(ctx).
+		Scopes(inWorkspace(workspaceId)).
+		Where("entity_id = ?", id)
+	if !before.IsZero() {
+		tx = tx.Where("created_at < ?", before)
+	}
+
+	var events []*models.EntityEvent
+	if err := tx.
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&events).
+		Error; err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 && before.IsZero() {
+		return nil, ErrEntityNotFound
+	}
+
+	return events, nil
+}
+
+// QueryLocationAt
+// this method answers where the entity was at the given time, including the path of
+// its ancestors as they were named and nested back then.
+func (g *GormAdapter) QueryLocationAt(ctx context.Context, id string, at time.Time) (*models.Location, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := g.db.WithContext(ctx)
+
+	event, err := eventAt(tx, workspaceId, id, at)
+	if err != nil {
+		return nil, err
+	}
+
+	location := &models.Location{
+		EntityId: id,
+		At:       at,
+		Deleted:  event.Action == models.EntityActionDelete,
+		ParentId: event.ParentId,
+		Path:     make([]*models.EntitySnapshot, 0),
+	}
+
+	seen := map[string]bool{id: true}
+	for parentId := event.ParentId; parentId != nil && !seen[*parentId]; {
+		if len(location.Path) >= maxLocationDepth {
+			break
+		}
+		seen[*parentId] = true
+
+		parentEvent, err := eventAt(tx, workspaceId, *parentId, at)
+		if errors.Is(err, ErrNoHistory) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if parentEvent.After == nil {
+			break
+		}
+
+		location.Path = append([]*models.EntitySnapshot{parentEvent.After}, location.Path...)
+		parentId = parentEvent.ParentId
+	}
+
+	return location, nil
+}
+
+////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////
+
+// eventAt
+// returns the last event of the entity at or before the given time.
+func eventAt(tx *gorm.DB, workspaceId string, id string, at time.Time) (*models.EntityEvent, error) {
+	var event models.EntityEvent
+	if err := tx.
+		Scopes(inWorkspace(workspaceId)).
+		Where("entity_id = ? AND created_at <= ?", id, at).
+		Order("created_at DESC, id DESC").
+		First(&event).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoHistory
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// snapshotOf
+// loads the entity, deleted or not, with its tags and copies its current state.
+func snapshotOf(tx *gorm.DB, id string) (*models.EntitySnapshot, error) {
+	var entity models.Entity
+	if err := tx.
+		Unscoped().
+		Preload("Tags").
+		First(&entity, "id = ?", id).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEntityNotFound
+		}
+		return nil, err
+	}
+	return models.NewEntitySnapshot(&entity), nil
+}
+
+// recordEvent
+// appends an event to the history within the transaction of the change. The actor and
+// the workspace are taken from the context of the transaction, see WithActor and
+// WithWorkspace. Updates that did not change anything are not recorded.
+func recordEvent(tx *gorm.DB, action models.EntityAction, before, after *models.EntitySnapshot) error {
+	if action == models.EntityActionUpdate && reflect.DeepEqual(before, after) {
+		return nil
+	}
+
+	workspaceId, err := requireWorkspace(tx.Statement.Context)
+	if err != nil {
+		return err
+	}
+
+	event := &models.EntityEvent{
+		WorkspaceId: workspaceId,
+		Action:      action,
+		Actor:       ActorFromContext(tx.Statement.Context),
+		Before:      before,
+		After:       after,
+	}
+	if after != nil {
+		event.EntityId = after.Id
+		event.ParentId = after.ParentId
+	} else {
+		event.EntityId = before.Id
+	}
+
+	return tx.Create(event).Error
+}
+
+// recordChange
+// snapshots the entity, runs the change and records it as a single event.
+func recordChange(tx *gorm.DB, id string, action models.EntityAction, change func() error) error {
+	before, err := snapshotOf(tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := change(); err != nil {
+		return err
+	}
+
+	if action == models.EntityActionDelete {
+		return recordEvent(tx, action, before, nil)
+	}
+
+	after, err := snapshotOf(tx, id)
+	if err != nil {
+		return err
+	}
+	return recordEvent(tx, action, before, after)
+}
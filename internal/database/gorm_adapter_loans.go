@@ -0,0 +1,284 @@
+package database
+
+import (
+	"Backend/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+////////////////////////////////////////////////
+// Loan Methods
+////////////////////////////////////////////////
+
+// CheckOut
+// this method lends the entity, and everything inside it, to the borrower. Entities that
+// are checked out already, sit inside a checked out container or hold a checked out entity
+// are refused, the error names the entity that is lent. Check-outs are serialised with an
+// advisory lock so that a container and its contents cannot be lent at the same time.
+func (g *GormAdapter) CheckOut(
+	ctx context.Context,
+	id string,
+	borrower string,
+	dueAt *time.Time,
+	note string,
+) (*models.Loan, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	loan := &models.Loan{
+		WorkspaceId:  workspaceId,
+		EntityId:     id,
+		Borrower:     borrower,
+		Note:         note,
+		DueAt:        dueAt,
+		CheckedOutBy: ActorFromContext(ctx),
+	}
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The loans of ancestors and descendants are checked, which a row lock cannot cover
+		if err := dialectOf(tx).lock(tx, loanLockKey); err != nil {
+			return err
+		}
+
+		var entity models.Entity
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scopes(inWorkspace(workspaceId)).
+			First(&entity, "id = ?", id).
+			Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEntityNotFound
+			}
+			return err
+		}
+
+		ancestors, err := ancestorIds(tx, id)
+		if err != nil {
+			return err
+		}
+		descendants, err := subtreeIds(tx, id, false)
+		if err != nil {
+			return err
+		}
+
+		var lent []string
+		if err := tx.
+			Model(&models.Loan{}).
+			Where("entity_id IN ? AND returned_at IS NULL", append(ancestors, descendants...)).
+			Order("entity_id").
+			Limit(1).
+			Pluck("entity_id", &lent).
+			Error; err != nil {
+			return err
+		}
+		if len(lent) > 0 {
+			return fmt.Errorf("%w: %s", ErrAlreadyCheckedOut, lent[0])
+		}
+
+		return tx.Create(loan).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	return g.queryLoan(ctx, loan.Id)
+}
+
+// CheckIn
+// this method ends the active loan of the entity.
+func (g *GormAdapter) CheckIn(ctx context.Context, id string) (*models.Loan, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var loan models.Loan
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scopes(inWorkspace(workspaceId)).
+			Where("entity_id = ? AND returned_at IS NULL", id).
+			First(&loan).
+			Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotCheckedOut
+			}
+			return err
+		}
+
+		actor := ActorFromContext(ctx)
+		return tx.
+			Model(&loan).
+			Updates(map[string]any{
+				"returned_at":   time.Now(),
+				"checked_in_by": actor,
+			}).
+			Error
+	}); err != nil {
+		return nil, err
+	}
+
+	return g.queryLoan(ctx, loan.Id)
+}
+
+// QueryActiveLoans
+// this method lists the loans that are not returned yet, soonest due first.
+func (g *GormAdapter) QueryActiveLoans(ctx context.Context) ([]*models.Loan, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryLoans(g.db.WithContext(ctx).Scopes(inWorkspace(workspaceId)).Where("returned_at IS NULL"))
+}
+
+// QueryOverdueLoans
+// this method lists the active loans whose due date lies before now, most overdue first.
+func (g *GormAdapter) QueryOverdueLoans(ctx context.Context, now time.Time) ([]*models.Loan, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryLoans(g.db.
+		WithContext(ctx).
+		Scopes(inWorkspace(workspaceId)).
+		Where("returned_at IS NULL AND due_at < ?", now))
+}
+
+func (g *GormAdapter) queryLoan(ctx context.Context, id string) (*models.Loan, error) {
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	loans, err := queryLoans(g.db.WithContext(ctx).Scopes(inWorkspace(workspaceId)).Where("id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+	if len(loans) == 0 {
+		return nil, ErrNotCheckedOut
+	}
+	return loans[0], nil
+}
+
+////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////
+
+// queryLoans
+// finds the loans with their entity, which may be in the trash by now, and its contents.
+func queryLoans(tx *gorm.DB) ([]*models.Loan, error) {
+	var loans []*models.Loan
+	if err := tx.
+		Preload("Entity", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		}).
+		Order("due_at ASC NULLS LAST, checked_out_at").
+		Find(&loans).
+		Error; err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(loans))
+	for _, l := range loans {
+		ids = append(ids, l.EntityId)
+	}
+	contents, err := contentsOf(tx.Session(&gorm.Session{NewDB: true}), ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range loans {
+		l.Contents = contents[l.EntityId]
+	}
+
+	return loans, nil
+}
+
+// contentsOf
+// returns the live descendants of each of the given entities ordered by name, keyed by the
+// entity id. All subtrees are walked with one recursive query.
+func contentsOf(tx *gorm.DB, ids []string) (map[string][]*models.Entity, error) {
+	res := make(map[string][]*models.Entity, len(ids))
+	if len(ids) == 0 {
+		return res, nil
+	}
+
+	var links []*struct {
+		Origin string
+		Id     string
+	}
+	if err := tx.
+		Raw(`
+			WITH RECURSIVE contents AS (
+				SELECT e.id AS origin, e.id
+				FROM entities e
+				WHERE e.id IN ? AND e.deleted_at IS NULL
+				UNION ALL
+				SELECT c.origin, e.id
+				FROM entities e
+				JOIN contents c ON e.parent_id = c.id
+				WHERE e.deleted_at IS NULL
+			)
+			SELECT origin, id FROM contents WHERE id <> origin`,
+			ids,
+		).
+		Scan(&links).
+		Error; err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return res, nil
+	}
+
+	origins := make(map[string][]string, len(links))
+	contentIds := make([]string, 0, len(links))
+	for _, l := range links {
+		if _, ok := origins[l.Id]; !ok {
+			contentIds = append(contentIds, l.Id)
+		}
+		origins[l.Id] = append(origins[l.Id], l.Origin)
+	}
+
+	var entities []*models.Entity
+	if err := tx.
+		Where("id IN ?", contentIds).
+		Order("name").
+		Find(&entities).
+		Error; err != nil {
+		return nil, err
+	}
+
+	for _, e := range entities {
+		for _, origin := range origins[e.Id] {
+			res[origin] = append(res[origin], e)
+		}
+	}
+
+	return res, nil
+}
+
+// loanLockKey identifies the advisory lock taken by CheckOut
+const loanLockKey int64 = 0x74746c6e
@@ -0,0 +1,221 @@
+package database
+
+import (
+	"Backend/internal/models"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+////////////////////////////////////////////////
+// Role Methods
+////////////////////////////////////////////////
+
+// EffectiveRole
+// this method returns the role of the user on the entity: the strongest of their workspace
+// role and the grants on the entity or any of its ancestors. Without an entity id the
+// workspace role is returned. Fails with ErrWorkspaceNotFound unless the user is a member.
+func (g *GormAdapter) EffectiveRole(ctx context.Context, userId string, entityId string) (models.Role, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return "", err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var member models.WorkspaceMember
+	if err := g.db.
+		WithContext(ctx).
+		First(&member, "workspace_id = ? AND user_id = ?", workspaceId, userId).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrWorkspaceNotFound
+		}
+		return "", err
+	}
+
+	if entityId == "" || member.Role == models.RoleOwner {
+		return member.Role, nil
+	}
+
+	ids, err := lineageIds(g.db.WithContext(ctx), workspaceId, entityId)
+	if err != nil {
+		return "", err
+	}
+	if len(ids) == 0 {
+		return member.Role, nil
+	}
+
+	var granted []models.Role
+	if err := g.db.
+		WithContext(ctx).
+		Model(&models.SubtreeGrant{}).
+		Scopes(inWorkspace(workspaceId)).
+		Where("user_id = ? AND entity_id IN ?", userId, ids).
+		Pluck("role", &granted).
+		Error; err != nil {
+		return "", err
+	}
+
+	return models.HighestRole(append(granted, member.Role)...), nil
+}
+
+// QueryGrants
+// this method lists the grants that apply to the entity, the ones on its ancestors included.
+func (g *GormAdapter) QueryGrants(ctx context.Context, entityId string) ([]*models.SubtreeGrant, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := lineageIds(g.db.WithContext(ctx), workspaceId, entityId)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, ErrEntityNotFound
+	}
+
+	var grants []*models.SubtreeGrant
+	if err := g.db.
+		WithContext(ctx).
+		Preload("User").
+		Scopes(inWorkspace(workspaceId)).
+		Where("entity_id IN ?", ids).
+		Order("created_at").
+		Find(&grants).
+		Error; err != nil {
+		return nil, err
+	}
+
+	return grants, nil
+}
+
+// GrantRole
+// this method gives a member the role on the entity and everything below it, replacing an
+// earlier grant of theirs on the same entity.
+func (g *GormAdapter) GrantRole(
+	ctx context.Context,
+	entityId string,
+	userId string,
+	role models.Role,
+) (*models.SubtreeGrant, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	grant := &models.SubtreeGrant{
+		WorkspaceId: workspaceId,
+		UserId:      userId,
+		EntityId:    entityId,
+		Role:        role,
+	}
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureInWorkspace(tx, workspaceId, entityId); err != nil {
+			return err
+		}
+
+		var member models.WorkspaceMember
+		if err := tx.
+			Preload("User").
+			First(&member, "workspace_id = ? AND user_id = ?", workspaceId, userId).
+			Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		if err := tx.
+			Omit("User").
+			Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "entity_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"role"}),
+			}).
+			Create(grant).
+			Error; err != nil {
+			return err
+		}
+
+		// Reload so that an updated grant keeps its id and creation time
+		grant = &models.SubtreeGrant{User: member.User}
+		return tx.
+			First(grant, "user_id = ? AND entity_id = ?", userId, entityId).
+			Error
+	}); err != nil {
+		return nil, err
+	}
+
+	return grant, nil
+}
+
+func (g *GormAdapter) RevokeGrant(ctx context.Context, entityId string, userId string) error {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return err
+	}
+
+	res := g.db.
+		WithContext(ctx).
+		Scopes(inWorkspace(workspaceId)).
+		Where("entity_id = ? AND user_id = ?", entityId, userId).
+		Delete(&models.SubtreeGrant{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrGrantNotFound
+	}
+
+	return nil
+}
+
+////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////
+
+// lineageIds
+// returns the id of the entity followed by the ids of its ancestors, walking up the ParentId
+// chain. Deleted entities are walked too, so that roles still apply to entities in the trash.
+// Returns nothing when the entity is not part of the workspace.
+func lineageIds(tx *gorm.DB, workspaceId string, id string) ([]string, error) {
+	var ids []string
+
+	if err := tx.
+		Raw(`
+			WITH RECURSIVE lineage AS (
+				SELECT id, parent_id, 0 AS depth
+				FROM entities
+				WHERE id = ? AND workspace_id = ?
+				UNION ALL
+				SELECT e.id, e.parent_id, l.depth + 1
+				FROM entities e
+				JOIN lineage l ON e.id = l.parent_id
+			)
+			SELECT id FROM lineage ORDER BY depth`,
+			id, workspaceId,
+		).
+		Scan(&ids).
+		Error; err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"Backend/internal/models"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+////////////////////////////////////////////////
+// Stock Methods
+////////////////////////////////////////////////
+
+// AdjustQuantity
+// this method changes the quantity of an entity by delta and records the adjustment.
+// The row is locked for the duration of the transaction, so concurrent adjustments
+// are applied one after another and can never take the quantity below zero.
+func (g *GormAdapter) AdjustQuantity(
+	ctx context.Context,
+	id string,
+	delta int64,
+	reason string,
+) (*models.Entity, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var entity models.Entity
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scopes(inWorkspace(workspaceId)).
+			First(&entity, "id = ?", id).
+			Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEntityNotFound
+			}
+			return err
+		}
+
+		quantity := entity.Quantity + delta
+		if quantity < 0 {
+			return ErrInsufficientQuantity
+		}
+
+		if err := recordChange(tx, id, models.EntityActionUpdate, func() error {
+			return tx.
+				Model(&entity).
+				Update("quantity", quantity).
+				Error
+		}); err != nil {
+			return err
+		}
+
+		return tx.Create(&models.StockAdjustment{
+			EntityId: id,
+			Delta:    delta,
+			Quantity: quantity,
+			Reason:   reason,
+		}).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	return g.QueryById(ctx, id)
+}
+
+// QueryLowStock
+// this method returns the entities with a threshold whose quantity is at or below it.
+func (g *GormAdapter) QueryLowStock(ctx context.Context) ([]*models.Entity, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var entities []*models.Entity
+	if err := g.db.
+		WithContext(ctx).
+		Scopes(inWorkspace(workspaceId)).
+		Preload("Tags").
+		Where("min_quantity IS NOT NULL AND quantity <= min_quantity").
+		Order("name").
+		Find(&entities).
+		Error; err != nil {
+		return nil, err
+	}
+
+	return entities, nil
+}
@@ -0,0 +1,229 @@
+package database
+
+import (
+	"Backend/internal/models"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+)
+
+////////////////////////////////////////////////
+// Tag Methods
+////////////////////////////////////////////////
+
+func (g *GormAdapter) CreateTag(ctx context.Context, name string) (*models.Tag, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := &models.Tag{WorkspaceId: workspaceId, Name: name}
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureTagNameFree(tx, workspaceId, name, ""); err != nil {
+			return err
+		}
+		return tx.Create(tag).Error
+	}); err != nil {
+		return nil, duplicateAs(err, func() error {
+			return ensureTagNameFree(g.db.WithContext(ctx), workspaceId, name, "")
+		})
+	}
+
+	return tag, nil
+}
+
+func (g *GormAdapter) RenameTag(ctx context.Context, id string, name string) (*models.Tag, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var tag models.Tag
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Scopes(inWorkspace(workspaceId)).
+			First(&tag, "id = ?", id).
+			Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTagNotFound
+			}
+			return err
+		}
+		if err := ensureTagNameFree(tx, workspaceId, name, id); err != nil {
+			return err
+		}
+		return tx.Model(&tag).Update("name", name).Error
+	}); err != nil {
+		return nil, duplicateAs(err, func() error {
+			return ensureTagNameFree(g.db.WithContext(ctx), workspaceId, name, id)
+		})
+	}
+
+	return &tag, nil
+}
+
+func (g *GormAdapter) QueryTags(ctx context.Context) ([]*models.Tag, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []*models.Tag
+	if err := g.db.
+		WithContext(ctx).
+		Scopes(inWorkspace(workspaceId)).
+		Order("name").
+		Find(&tags).
+		Error; err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// AttachTags
+// this method adds the named tags to the entity, tags that do not exist yet are created
+// unless the context is WithoutTagCreation.
+func (g *GormAdapter) AttachTags(ctx context.Context, entityId string, names ...string) (*models.Entity, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var entity models.Entity
+		if err := tx.
+			Scopes(inWorkspace(workspaceId)).
+			First(&entity, "id = ?", entityId).
+			Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEntityNotFound
+			}
+			return err
+		}
+
+		tags, err := resolveTags(tx, workspaceId, names, tagCreationAllowed(ctx))
+		if err != nil {
+			return err
+		}
+
+		return recordChange(tx, entityId, models.EntityActionUpdate, func() error {
+			return tx.Model(&entity).Association("Tags").Append(tags)
+		})
+	}); err != nil {
+		// Tags are the only unique rows created here, a concurrent request created one of them first
+		return nil, duplicateAs(err, func() error { return ErrTagExists })
+	}
+
+	return g.QueryById(ctx, entityId)
+}
+
+func (g *GormAdapter) DetachTag(ctx context.Context, entityId string, tagId string) (*models.Entity, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspaceId, err := requireWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var entity models.Entity
+		if err := tx.
+			Scopes(inWorkspace(workspaceId)).
+			First(&entity, "id = ?", entityId).
+			Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEntityNotFound
+			}
+			return err
+		}
+
+		var tag models.Tag
+		if err := tx.
+			Scopes(inWorkspace(workspaceId)).
+			First(&tag, "id = ?", tagId).
+			Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTagNotFound
+			}
+			return err
+		}
+
+		return recordChange(tx, entityId, models.EntityActionUpdate, func() error {
+			return tx.Model(&entity).Association("Tags").Delete(&tag)
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	return g.QueryById(ctx, entityId)
+}
+
+////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////
+
+// ensureTagNameFree
+// fails with ErrTagExists when a tag of the workspace other than exceptId already uses the name.
+func ensureTagNameFree(tx *gorm.DB, workspaceId string, name string, exceptId string) error {
+	var count int64
+	if err := tx.
+		Model(&models.Tag{}).
+		Scopes(inWorkspace(workspaceId)).
+		Where("name = ? AND id <> ?", name, exceptId).
+		Count(&count).
+		Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrTagExists
+	}
+	return nil
+}
+
+// resolveTags
+// returns the tags with the given names, creating the ones that do not exist yet unless
+// create is false, see WithoutTagCreation.
+func resolveTags(tx *gorm.DB, workspaceId string, names []string, create bool) ([]*models.Tag, error) {
+	names = models.NormalizeTagNames(names)
+	tags := make([]*models.Tag, 0, len(names))
+
+	for _, name := range names {
+		tag := &models.Tag{}
+		query := tx.Where(models.Tag{WorkspaceId: workspaceId, Name: name})
+		if create {
+			query = query.FirstOrCreate(tag)
+		} else {
+			query = query.First(tag)
+		}
+		if err := query.Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrTagCreationDenied
+			}
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
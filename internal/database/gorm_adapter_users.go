@@ -0,0 +1,175 @@
+package database
+
+import (
+	"Backend/internal/models"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+// dummyUser is checked against when the username is unknown, so that the
+// response time does not tell which usernames exist
+var dummyUser = sync.OnceValue(func() *models.User {
+	u, _ := models.NewUser("dummy", "dummy-password")
+	return u
+})
+
+////////////////////////////////////////////////
+// User Methods
+////////////////////////////////////////////////
+
+func (g *GormAdapter) CreateUser(ctx context.Context, user *models.User) error {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return err
+	}
+
+	return g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.
+			Model(&models.User{}).
+			Where("username = ?", user.Username).
+			Count(&count).
+			Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrUserExists
+		}
+
+		return tx.Create(user).Error
+	})
+}
+
+// EnsureAdmin
+// this method creates the given user when there are no users at all, so that a fresh
+// installation can be logged into. The user joins the default workspace.
+// It reports whether the user was created.
+func (g *GormAdapter) EnsureAdmin(ctx context.Context, user *models.User) (bool, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return false, err
+	}
+
+	created := false
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.User{}).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		workspace, err := defaultWorkspace(tx)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+
+		created = true
+		return tx.Create(&models.WorkspaceMember{
+			WorkspaceId: workspace.Id,
+			UserId:      user.Id,
+			Role:        models.RoleOwner,
+		}).Error
+	}); err != nil {
+		return false, err
+	}
+
+	return created, nil
+}
+
+// Login
+// this method checks the credentials and issues a token valid for ttl.
+// Expired tokens of the user are cleaned up on the way.
+func (g *GormAdapter) Login(
+	ctx context.Context,
+	username string,
+	password string,
+	ttl time.Duration,
+) (string, *models.AuthToken, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return "", nil, err
+	}
+
+	var user models.User
+	if err := g.db.
+		WithContext(ctx).
+		First(&user, "username = ?", models.NormalizeUsername(username)).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			dummyUser().CheckPassword(password)
+			return "", nil, ErrInvalidCredentials
+		}
+		return "", nil, err
+	}
+
+	if !user.CheckPassword(password) {
+		return "", nil, ErrInvalidCredentials
+	}
+
+	token, record, err := models.NewAuthToken(user.Id, ttl)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Where("user_id = ? AND expires_at < ?", user.Id, time.Now()).
+			Delete(&models.AuthToken{}).
+			Error; err != nil {
+			return err
+		}
+		return tx.Create(record).Error
+	}); err != nil {
+		return "", nil, err
+	}
+
+	record.User = &user
+	return token, record, nil
+}
+
+// QueryUserByToken
+// this method returns the user a token was issued to, expired tokens are invalid.
+func (g *GormAdapter) QueryUserByToken(ctx context.Context, token string) (*models.User, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	var record models.AuthToken
+	if err := g.db.
+		WithContext(ctx).
+		Preload("User").
+		Where("token_hash = ? AND expires_at > ?", models.HashToken(token), time.Now()).
+		First(&record).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	if record.User == nil {
+		return nil, ErrInvalidToken
+	}
+
+	return record.User, nil
+}
+
+// Logout
+// this method revokes the token.
+func (g *GormAdapter) Logout(ctx context.Context, token string) error {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return err
+	}
+
+	return g.db.
+		WithContext(ctx).
+		Where("token_hash = ?", models.HashToken(token)).
+		Delete(&models.AuthToken{}).
+		Error
+}
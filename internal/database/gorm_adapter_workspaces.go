@@ -0,0 +1,328 @@
+package database
+
+import (
+	"Backend/internal/models"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+////////////////////////////////////////////////
+// Workspace Methods
+////////////////////////////////////////////////
+
+// CreateWorkspace
+// this method creates a workspace with the given user as its first member and owner.
+func (g *GormAdapter) CreateWorkspace(ctx context.Context, name string, userId string) (*models.Workspace, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	workspace := &models.Workspace{Name: name}
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(workspace).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.WorkspaceMember{
+			WorkspaceId: workspace.Id,
+			UserId:      userId,
+			Role:        models.RoleOwner,
+		}).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	return workspace, nil
+}
+
+// QueryWorkspacesOfUser
+// this method lists the workspaces the user is a member of, in the order they joined.
+func (g *GormAdapter) QueryWorkspacesOfUser(ctx context.Context, userId string) ([]*models.Workspace, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	var workspaces []*models.Workspace
+	if err := g.db.
+		WithContext(ctx).
+		Joins("JOIN workspace_members ON workspace_members.workspace_id = workspaces.id").
+		Where("workspace_members.user_id = ?", userId).
+		Order("workspace_members.created_at, workspaces.id").
+		Find(&workspaces).
+		Error; err != nil {
+		return nil, err
+	}
+
+	return workspaces, nil
+}
+
+// QueryWorkspaceIds
+// this method lists every workspace, for background jobs that run across all of them.
+func (g *GormAdapter) QueryWorkspaceIds(ctx context.Context) ([]string, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	if err := g.db.
+		WithContext(ctx).
+		Model(&models.Workspace{}).
+		Order("created_at").
+		Pluck("id", &ids).
+		Error; err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// QueryMembership
+// this method fails with ErrWorkspaceNotFound unless the user is a member of the workspace,
+// so that workspaces of others cannot be told apart from ones that do not exist.
+func (g *GormAdapter) QueryMembership(ctx context.Context, workspaceId string, userId string) (*models.WorkspaceMember, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	var member models.WorkspaceMember
+	if err := g.db.
+		WithContext(ctx).
+		Preload("User").
+		First(&member, "workspace_id = ? AND user_id = ?", workspaceId, userId).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWorkspaceNotFound
+		}
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// QueryWorkspaceMembers
+// this method lists the members of the workspace in the order they joined.
+func (g *GormAdapter) QueryWorkspaceMembers(ctx context.Context, workspaceId string) ([]*models.WorkspaceMember, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	var members []*models.WorkspaceMember
+	if err := g.db.
+		WithContext(ctx).
+		Preload("User").
+		Where("workspace_id = ?", workspaceId).
+		Order("created_at").
+		Find(&members).
+		Error; err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (g *GormAdapter) AddWorkspaceMember(
+	ctx context.Context,
+	workspaceId string,
+	username string,
+	role models.Role,
+) (*models.WorkspaceMember, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	var member *models.WorkspaceMember
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, "username = ?", models.NormalizeUsername(username)).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		var count int64
+		if err := tx.
+			Model(&models.WorkspaceMember{}).
+			Where("workspace_id = ? AND user_id = ?", workspaceId, user.Id).
+			Count(&count).
+			Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrMemberExists
+		}
+
+		member = &models.WorkspaceMember{
+			WorkspaceId: workspaceId,
+			UserId:      user.Id,
+			User:        &user,
+			Role:        role,
+		}
+		return tx.Omit("User", "Workspace").Create(member).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// UpdateMemberRole
+// this method changes the role of a member, the last owner of a workspace cannot be demoted.
+func (g *GormAdapter) UpdateMemberRole(
+	ctx context.Context,
+	workspaceId string,
+	userId string,
+	role models.Role,
+) (*models.WorkspaceMember, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	var member models.WorkspaceMember
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Preload("User").
+			First(&member, "workspace_id = ? AND user_id = ?", workspaceId, userId).
+			Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		if member.Role == models.RoleOwner && role != models.RoleOwner {
+			if err := ensureOtherOwner(tx, workspaceId, userId); err != nil {
+				return err
+			}
+		}
+
+		return tx.
+			Model(&member).
+			Update("role", role).
+			Error
+	}); err != nil {
+		return nil, err
+	}
+
+	member.Role = role
+	return &member, nil
+}
+
+// RemoveWorkspaceMember
+// this method removes a member together with their grants and the API keys they created,
+// the last owner cannot leave.
+func (g *GormAdapter) RemoveWorkspaceMember(ctx context.Context, workspaceId string, userId string) error {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return err
+	}
+
+	return g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var member models.WorkspaceMember
+		if err := tx.
+			First(&member, "workspace_id = ? AND user_id = ?", workspaceId, userId).
+			Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		if member.Role == models.RoleOwner {
+			if err := ensureOtherOwner(tx, workspaceId, userId); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.
+			Where("workspace_id = ? AND user_id = ?", workspaceId, userId).
+			Delete(&models.SubtreeGrant{}).
+			Error; err != nil {
+			return err
+		}
+
+		// Keys outlive their creator otherwise, QueryApiKeyByToken rejects them either way
+		if err := tx.
+			Where("workspace_id = ? AND created_by = ?", workspaceId, userId).
+			Delete(&models.ApiKey{}).
+			Error; err != nil {
+			return err
+		}
+
+		return tx.
+			Delete(&models.WorkspaceMember{}, "workspace_id = ? AND user_id = ?", workspaceId, userId).
+			Error
+	})
+}
+
+// QueryEntityWorkspace
+// this method returns the workspace of an entity, deleted or not. It is meant for
+// resources named after an entity, e.g. images uploaded before workspaces existed.
+func (g *GormAdapter) QueryEntityWorkspace(ctx context.Context, entityId string) (string, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return "", err
+	}
+
+	var entity models.Entity
+	if err := g.db.
+		WithContext(ctx).
+		Unscoped().
+		Select("workspace_id").
+		First(&entity, "id = ?", entityId).
+		Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrEntityNotFound
+		}
+		return "", err
+	}
+
+	return entity.WorkspaceId, nil
+}
+
+////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////
+
+// ensureOtherOwner
+// fails with ErrLastOwner unless the workspace has an owner besides the given user. The owners
+// are locked so that two owners cannot demote each other at the same time.
+func ensureOtherOwner(tx *gorm.DB, workspaceId string, userId string) error {
+	var owners []string
+	if err := tx.
+		Model(&models.WorkspaceMember{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("workspace_id = ? AND role = ?", workspaceId, models.RoleOwner).
+		Pluck("user_id", &owners).
+		Error; err != nil {
+		return err
+	}
+
+	for _, owner := range owners {
+		if owner != userId {
+			return nil
+		}
+	}
+	return ErrLastOwner
+}
+
+// defaultWorkspace
+// returns the oldest workspace, creating it when there is none yet.
+func defaultWorkspace(tx *gorm.DB) (*models.Workspace, error) {
+	var workspace models.Workspace
+	if err := tx.
+		Order("created_at").
+		First(&workspace).
+		Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		workspace = models.Workspace{Name: models.DefaultWorkspaceName}
+		if err := tx.Create(&workspace).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &workspace, nil
+}
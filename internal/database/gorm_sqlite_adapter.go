@@ -0,0 +1,99 @@
+package database
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// sqliteUtcDriver is the name the SQLite driver binding times in UTC is registered under
+const sqliteUtcDriver = "sqlite3_utc"
+
+func init() {
+	sql.Register(sqliteUtcDriver, &utcDriver{})
+}
+
+////////////////////////////////////////////////
+// Constructors
+////////////////////////////////////////////////
+
+// CreateGormSqliteAdapter
+// returns a GormAdapter on a single SQLite file, for installations without a database server.
+// Row locks do not exist in SQLite, instead every transaction takes the write lock when it begins.
+func CreateGormSqliteAdapter(path string) (*GormAdapter, error) {
+	if path == "" {
+		return nil, errors.New("a path to the SQLite database file is required")
+	}
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, fmt.Errorf("unable to create the directory of the database: %w", err)
+		}
+	}
+
+	dsn := fmt.Sprintf("file:%s?%s", path, url.Values{
+		"_foreign_keys": {"on"},
+		"_journal_mode": {"WAL"},
+		"_busy_timeout": {"5000"},
+		"_txlock":       {"immediate"},
+	}.Encode())
+
+	return &GormAdapter{
+		open: func() gorm.Dialector {
+			return sqlite.New(sqlite.Config{DriverName: sqliteUtcDriver, DSN: dsn})
+		},
+		config: &gorm.Config{
+			// Unique violations become gorm.ErrDuplicatedKey, see duplicateAs
+			TranslateError: true,
+			// Times are stored as text and compared as such, so they all have to share a zone,
+			// see utcConn for the times passed in
+			NowFunc: func() time.Time {
+				return time.Now().UTC()
+			},
+		},
+	}, nil
+}
+
+////////////////////////////////////////////////
+// Driver
+////////////////////////////////////////////////
+
+// utcDriver
+// opens SQLite connections that bind times in UTC. The driver writes them as text in their
+// own zone, and text in different zones does not compare like the times it stands for.
+type utcDriver struct {
+	sqlite3.SQLiteDriver
+}
+
+func (d *utcDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.SQLiteDriver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &utcConn{conn.(*sqlite3.SQLiteConn)}, nil
+}
+
+// utcConn is a SQLite connection that converts the times of query arguments to UTC
+type utcConn struct {
+	*sqlite3.SQLiteConn
+}
+
+func (c *utcConn) CheckNamedValue(nv *driver.NamedValue) error {
+	value, err := driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC()
+	}
+	nv.Value = value
+	return nil
+}
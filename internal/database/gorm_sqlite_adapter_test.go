@@ -0,0 +1,777 @@
+package database
+
+import (
+	"Backend/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newSqliteWorkspace migrates a fresh database file and returns a context scoped to a new workspace
+func newSqliteWorkspace(t *testing.T) (*GormAdapter, context.Context) {
+	t.Helper()
+
+	db, err := CreateGormSqliteAdapter(filepath.Join(t.TempDir(), "tt.db"))
+	if err != nil {
+		t.Fatalf("unable to create adapter: %v", err)
+	}
+	ctx := context.Background()
+	if err := db.Migrate(ctx); err != nil {
+		t.Fatalf("unable to migrate: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Disconnect(ctx) })
+
+	user, err := models.NewUser("alice", "correct horse")
+	if err != nil {
+		t.Fatalf("unable to create user: %v", err)
+	}
+	if err := db.CreateUser(ctx, user); err != nil {
+		t.Fatalf("unable to store user: %v", err)
+	}
+	workspace, err := db.CreateWorkspace(ctx, "Home", user.Id)
+	if err != nil {
+		t.Fatalf("unable to create workspace: %v", err)
+	}
+
+	return db, WithWorkspace(ctx, workspace.Id)
+}
+
+func TestSqliteEntityLifecycle(t *testing.T) {
+	db, ctx := newSqliteWorkspace(t)
+
+	for _, e := range []*models.Entity{
+		models.NewEntity(models.EntityWithId("garage"), models.EntityWithName("Garage")),
+		models.NewEntity(models.EntityWithId("shelf"), models.EntityWithName("Shelf"), models.EntityWithParentId("garage")),
+		models.NewEntity(
+			models.EntityWithId("drill"),
+			models.EntityWithName("Cordless drill"),
+			models.EntityWithDescription("18V with two batteries"),
+			models.EntityWithParentId("shelf"),
+			models.EntityWithImages([]string{"ws/drill_a", "ws/drill_b"}),
+			models.EntityWithAttributes(models.Attributes{"voltage": 18.0, "cordless": true}),
+		),
+	} {
+		if err := db.CreateEntity(ctx, e); err != nil {
+			t.Fatalf("unable to create %s: %v", e.Id, err)
+		}
+	}
+
+	drill, err := db.QueryById(ctx, "drill")
+	if err != nil {
+		t.Fatalf("unable to query: %v", err)
+	}
+	if len(drill.Images) != 2 || drill.Images[1] != "ws/drill_b" {
+		t.Errorf("expected the images to round-trip, got %v", drill.Images)
+	}
+	var stored string
+	if err := db.db.Raw("SELECT images FROM entities WHERE id = ?", "drill").Scan(&stored).Error; err != nil || stored != `["ws/drill_a","ws/drill_b"]` {
+		t.Errorf("expected the images to be stored as a JSON array, got %q (%v)", stored, err)
+	}
+
+	ancestors, err := db.QueryAncestors(ctx, "drill")
+	if err != nil || len(ancestors) != 2 || ancestors[0].Id != "garage" {
+		t.Errorf("expected garage and shelf as ancestors, got %v (%v)", ancestors, err)
+	}
+
+	hits, err := db.SearchEntities(ctx, "drill batteries", 10)
+	if err != nil || len(hits) != 1 || hits[0].Entity.Id != "drill" {
+		t.Errorf("expected the search to find the drill, got %v (%v)", hits, err)
+	}
+
+	for _, raw := range []string{"voltage>=18", "cordless=true"} {
+		cond, err := ParseAttributeCondition(raw)
+		if err != nil {
+			t.Fatalf("unable to parse %q: %v", raw, err)
+		}
+		page, err := db.QueryFiltered(ctx, PageRequest{Limit: 10, Sort: SortByName}, EntityFilter{
+			Attributes: []*AttributeCondition{cond},
+		})
+		if err != nil || len(page.Entities) != 1 {
+			t.Errorf("expected %q to match the drill, got %v (%v)", raw, page, err)
+		}
+	}
+
+	if _, err := db.MoveEntity(ctx, "garage", ptr("drill")); err != ErrMoveCycle {
+		t.Errorf("expected ErrMoveCycle, got %v", err)
+	}
+	if _, err := db.MoveEntity(ctx, "drill", ptr("garage")); err != nil {
+		t.Errorf("unable to move: %v", err)
+	}
+
+	if _, err := db.AdjustQuantity(ctx, "drill", -2, "lent"); err != ErrInsufficientQuantity {
+		t.Errorf("expected ErrInsufficientQuantity, got %v", err)
+	}
+
+	if _, err := db.CheckOut(ctx, "garage", "Sam", nil, ""); err != nil {
+		t.Fatalf("unable to check out: %v", err)
+	}
+	if _, err := db.CheckOut(ctx, "drill", "Kim", nil, ""); !errors.Is(err, ErrAlreadyCheckedOut) {
+		t.Errorf("expected ErrAlreadyCheckedOut for an entity in a lent container, got %v", err)
+	}
+
+	history, err := db.QueryHistory(ctx, "drill", 10, time.Time{})
+	if err != nil || len(history) < 2 {
+		t.Errorf("expected the create and the move in the history, got %v (%v)", history, err)
+	}
+}
+
+func TestSqliteAdjustQuantityRefusesNegativeStock(t *testing.T) {
+	db, ctx := newSqliteWorkspace(t)
+
+	if err := db.CreateEntity(ctx, models.NewEntity(models.EntityWithId("screws"), models.EntityWithName("Screws"), models.EntityWithQuantity(3))); err != nil {
+		t.Fatalf("unable to create entity: %v", err)
+	}
+
+	entity, err := db.AdjustQuantity(ctx, "screws", -3, "used")
+	if err != nil {
+		t.Fatalf("unable to adjust the quantity down to zero: %v", err)
+	}
+	if entity.Quantity != 0 {
+		t.Errorf("expected a quantity of 0, got %d", entity.Quantity)
+	}
+
+	if _, err := db.AdjustQuantity(ctx, "screws", -1, "used"); !errors.Is(err, ErrInsufficientQuantity) {
+		t.Fatalf("expected ErrInsufficientQuantity below zero, got %v", err)
+	}
+	entity, err = db.QueryById(ctx, "screws")
+	if err != nil {
+		t.Fatalf("unable to query entity: %v", err)
+	}
+	if entity.Quantity != 0 {
+		t.Errorf("expected a refused adjustment to leave the quantity at 0, got %d", entity.Quantity)
+	}
+
+	var adjustments int64
+	if err := db.db.Model(&models.StockAdjustment{}).Where("entity_id = ?", "screws").Count(&adjustments).Error; err != nil {
+		t.Fatalf("unable to count adjustments: %v", err)
+	}
+	if adjustments != 1 {
+		t.Errorf("expected only the applied adjustment to be recorded, got %d", adjustments)
+	}
+}
+
+func TestSqliteCreateEntityKeepsZeroQuantity(t *testing.T) {
+	db, ctx := newSqliteWorkspace(t)
+
+	for _, e := range []*models.Entity{
+		models.NewEntity(models.EntityWithId("empty"), models.EntityWithName("Empty"), models.EntityWithQuantity(0)),
+		models.NewEntity(models.EntityWithId("single"), models.EntityWithName("Single")),
+	} {
+		if err := db.CreateEntity(ctx, e); err != nil {
+			t.Fatalf("unable to create %s: %v", e.Id, err)
+		}
+	}
+
+	for id, expected := range map[string]int64{"empty": 0, "single": 1} {
+		entity, err := db.QueryById(ctx, id)
+		if err != nil {
+			t.Fatalf("unable to query %s: %v", id, err)
+		}
+		if entity.Quantity != expected {
+			t.Errorf("expected %s to be stored with a quantity of %d, got %d", id, expected, entity.Quantity)
+		}
+	}
+}
+
+func TestSqliteLowStockIncludesTheThreshold(t *testing.T) {
+	db, ctx := newSqliteWorkspace(t)
+
+	threshold := int64(5)
+	for _, e := range []*models.Entity{
+		models.NewEntity(models.EntityWithId("below"), models.EntityWithName("Below"), models.EntityWithQuantity(4), models.EntityWithMinQuantity(&threshold)),
+		models.NewEntity(models.EntityWithId("at"), models.EntityWithName("At"), models.EntityWithQuantity(5), models.EntityWithMinQuantity(&threshold)),
+		models.NewEntity(models.EntityWithId("above"), models.EntityWithName("Above"), models.EntityWithQuantity(6), models.EntityWithMinQuantity(&threshold)),
+		models.NewEntity(models.EntityWithId("untracked"), models.EntityWithName("Untracked"), models.EntityWithQuantity(0)),
+	} {
+		if err := db.CreateEntity(ctx, e); err != nil {
+			t.Fatalf("unable to create %s: %v", e.Id, err)
+		}
+	}
+
+	low, err := db.QueryLowStock(ctx)
+	if err != nil {
+		t.Fatalf("unable to query low stock: %v", err)
+	}
+	ids := make([]string, 0, len(low))
+	for _, e := range low {
+		ids = append(ids, e.Id)
+	}
+	if expected := []string{"at", "below"}; !slices.Equal(ids, expected) {
+		t.Errorf("expected %v to be low on stock, got %v", expected, ids)
+	}
+
+	// Restocking above the threshold takes it off the report
+	if _, err := db.AdjustQuantity(ctx, "at", 1, "restocked"); err != nil {
+		t.Fatalf("unable to adjust the quantity: %v", err)
+	}
+	low, err = db.QueryLowStock(ctx)
+	if err != nil {
+		t.Fatalf("unable to query low stock: %v", err)
+	}
+	if len(low) != 1 || low[0].Id != "below" {
+		t.Errorf("expected only below to be low on stock after restocking, got %v", low)
+	}
+}
+
+func TestSqliteQueryLocationAtFollowsMoves(t *testing.T) {
+	db, ctx := newSqliteWorkspace(t)
+
+	// Times of the request are not necessarily in the zone the events are stored in
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	tick := func() time.Time {
+		time.Sleep(10 * time.Millisecond)
+		at := time.Now().In(zone)
+		time.Sleep(10 * time.Millisecond)
+		return at
+	}
+
+	beforeCreate := tick()
+	for _, e := range []*models.Entity{
+		models.NewEntity(models.EntityWithId("shelf"), models.EntityWithName("Shelf")),
+		models.NewEntity(models.EntityWithId("box"), models.EntityWithName("Box"), models.EntityWithParentId("shelf")),
+		models.NewEntity(models.EntityWithId("drill"), models.EntityWithName("Drill"), models.EntityWithParentId("shelf")),
+	} {
+		if err := db.CreateEntity(ctx, e); err != nil {
+			t.Fatalf("unable to create %s: %v", e.Id, err)
+		}
+	}
+	beforeMoves := tick()
+
+	box := "box"
+	if _, err := db.MoveEntity(ctx, "drill", &box); err != nil {
+		t.Fatalf("unable to move: %v", err)
+	}
+	betweenMoves := tick()
+
+	if _, err := db.MoveEntity(ctx, "drill", nil); err != nil {
+		t.Fatalf("unable to move: %v", err)
+	}
+	afterMoves := tick()
+
+	if _, err := db.QueryLocationAt(ctx, "drill", beforeCreate); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("expected ErrNoHistory before the entity was created, got %v", err)
+	}
+
+	for name, c := range map[string]struct {
+		at   time.Time
+		path []string
+	}{
+		"before the moves":  {at: beforeMoves, path: []string{"shelf"}},
+		"between the moves": {at: betweenMoves, path: []string{"shelf", "box"}},
+		"after the moves":   {at: afterMoves, path: []string{}},
+	} {
+		location, err := db.QueryLocationAt(ctx, "drill", c.at)
+		if err != nil {
+			t.Fatalf("unable to query the location %s: %v", name, err)
+		}
+		path := make([]string, 0, len(location.Path))
+		for _, s := range location.Path {
+			path = append(path, s.Id)
+		}
+		if !slices.Equal(path, c.path) {
+			t.Errorf("expected the path %v %s, got %v", c.path, name, path)
+		}
+	}
+}
+
+func TestSqliteTimesCompareAcrossZones(t *testing.T) {
+	db, ctx := newSqliteWorkspace(t)
+
+	if err := db.CreateEntity(ctx, models.NewEntity(models.EntityWithId("drill"), models.EntityWithName("Drill"))); err != nil {
+		t.Fatalf("unable to create entity: %v", err)
+	}
+	now := time.Now()
+	due := now.Add(time.Hour).In(time.FixedZone("UTC-12", -12*60*60))
+	if _, err := db.CheckOut(ctx, "drill", "Sam", &due, ""); err != nil {
+		t.Fatalf("unable to check out: %v", err)
+	}
+
+	overdue, err := db.QueryOverdueLoans(ctx, now.In(time.FixedZone("UTC+14", 14*60*60)))
+	if err != nil {
+		t.Fatalf("unable to query overdue loans: %v", err)
+	}
+	if len(overdue) != 0 {
+		t.Errorf("expected a loan due in an hour not to be overdue, got %d overdue", len(overdue))
+	}
+
+	overdue, err = db.QueryOverdueLoans(ctx, now.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("unable to query overdue loans: %v", err)
+	}
+	if len(overdue) != 1 {
+		t.Errorf("expected the loan to be overdue two hours later, got %d overdue", len(overdue))
+	}
+}
+
+func TestSqliteCheckOutContainerOfLentEntity(t *testing.T) {
+	db, ctx := newSqliteWorkspace(t)
+
+	for _, e := range []*models.Entity{
+		models.NewEntity(models.EntityWithId("toolbox"), models.EntityWithName("Toolbox")),
+		models.NewEntity(models.EntityWithId("hammer"), models.EntityWithName("Hammer"), models.EntityWithParentId("toolbox")),
+	} {
+		if err := db.CreateEntity(ctx, e); err != nil {
+			t.Fatalf("unable to create %s: %v", e.Id, err)
+		}
+	}
+
+	if _, err := db.CheckOut(ctx, "hammer", "Sam", nil, ""); err != nil {
+		t.Fatalf("unable to check out: %v", err)
+	}
+	_, err := db.CheckOut(ctx, "toolbox", "Kim", nil, "")
+	if !errors.Is(err, ErrAlreadyCheckedOut) {
+		t.Fatalf("expected ErrAlreadyCheckedOut for a container holding a lent entity, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "hammer") {
+		t.Errorf("expected the error to name the lent entity, got %v", err)
+	}
+
+	if _, err := db.CheckIn(ctx, "hammer"); err != nil {
+		t.Fatalf("unable to check in: %v", err)
+	}
+	if _, err := db.CheckOut(ctx, "toolbox", "Kim", nil, ""); err != nil {
+		t.Errorf("unable to check out the container once its content is back: %v", err)
+	}
+}
+
+func TestSqliteCheckOutContainerAndContentConcurrently(t *testing.T) {
+	db, ctx := newSqliteWorkspace(t)
+
+	for round := 0; round < 10; round++ {
+		container := fmt.Sprintf("toolbox-%d", round)
+		content := fmt.Sprintf("hammer-%d", round)
+		for _, e := range []*models.Entity{
+			models.NewEntity(models.EntityWithId(container), models.EntityWithName("Toolbox")),
+			models.NewEntity(models.EntityWithId(content), models.EntityWithName("Hammer"), models.EntityWithParentId(container)),
+		} {
+			if err := db.CreateEntity(ctx, e); err != nil {
+				t.Fatalf("unable to create %s: %v", e.Id, err)
+			}
+		}
+
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		errs := make([]error, 2)
+		for i, id := range []string{container, content} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				_, errs[i] = db.CheckOut(ctx, id, "Sam", nil, "")
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		lent := 0
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				lent++
+			case !errors.Is(err, ErrAlreadyCheckedOut):
+				t.Fatalf("expected ErrAlreadyCheckedOut, got %v", err)
+			}
+		}
+		if lent != 1 {
+			t.Fatalf("expected exactly one of the container and its content to be lent in round %d, got %d", round, lent)
+		}
+	}
+}
+
+func TestSqliteActiveLoansListTheirContents(t *testing.T) {
+	db, ctx := newSqliteWorkspace(t)
+
+	for _, e := range []*models.Entity{
+		models.NewEntity(models.EntityWithId("toolbox"), models.EntityWithName("Toolbox")),
+		models.NewEntity(models.EntityWithId("tray"), models.EntityWithName("Tray"), models.EntityWithParentId("toolbox")),
+		models.NewEntity(models.EntityWithId("hammer"), models.EntityWithName("Hammer"), models.EntityWithParentId("tray")),
+		models.NewEntity(models.EntityWithId("tent"), models.EntityWithName("Tent")),
+		models.NewEntity(models.EntityWithId("pegs"), models.EntityWithName("Pegs"), models.EntityWithParentId("tent")),
+		models.NewEntity(models.EntityWithId("ladder"), models.EntityWithName("Ladder")),
+	} {
+		if err := db.CreateEntity(ctx, e); err != nil {
+			t.Fatalf("unable to create %s: %v", e.Id, err)
+		}
+	}
+	for _, id := range []string{"toolbox", "tent", "ladder"} {
+		if _, err := db.CheckOut(ctx, id, "Sam", nil, ""); err != nil {
+			t.Fatalf("unable to check out %s: %v", id, err)
+		}
+	}
+
+	loans, err := db.QueryActiveLoans(ctx)
+	if err != nil {
+		t.Fatalf("unable to query loans: %v", err)
+	}
+
+	contents := make(map[string][]string, len(loans))
+	for _, l := range loans {
+		for _, e := range l.Contents {
+			contents[l.EntityId] = append(contents[l.EntityId], e.Id)
+		}
+	}
+	for id, expected := range map[string][]string{
+		"toolbox": {"hammer", "tray"},
+		"tent":    {"pegs"},
+		"ladder":  nil,
+	} {
+		if !slices.Equal(contents[id], expected) {
+			t.Errorf("expected the contents of %s to be %v, got %v", id, expected, contents[id])
+		}
+	}
+}
+
+func TestSqliteCodesAreUniquePerWorkspace(t *testing.T) {
+	db, ctx := newSqliteWorkspace(t)
+
+	bob, err := models.NewUser("bob", "correct horse")
+	if err != nil {
+		t.Fatalf("unable to create user: %v", err)
+	}
+	if err := db.CreateUser(ctx, bob); err != nil {
+		t.Fatalf("unable to store user: %v", err)
+	}
+	other, err := db.CreateWorkspace(ctx, "Office", bob.Id)
+	if err != nil {
+		t.Fatalf("unable to create workspace: %v", err)
+	}
+	otherCtx := WithWorkspace(ctx, other.Id)
+
+	if err := db.CreateEntity(ctx, models.NewEntity(models.EntityWithName("Drill"), models.EntityWithCode("DRILL1"))); err != nil {
+		t.Fatalf("unable to create entity: %v", err)
+	}
+	if err := db.CreateEntity(otherCtx, models.NewEntity(models.EntityWithName("Drill"), models.EntityWithCode("DRILL1"))); err != nil {
+		t.Errorf("unable to reuse the code in another workspace: %v", err)
+	}
+	if err := db.CreateEntity(ctx, models.NewEntity(models.EntityWithName("Saw"), models.EntityWithCode("DRILL1"))); !errors.Is(err, ErrCodeInUse) {
+		t.Errorf("expected ErrCodeInUse within the workspace, got %v", err)
+	}
+}
+
+func TestSqliteIdsTakenInAnotherWorkspaceAreConflicts(t *testing.T) {
+	db, ctx := newSqliteWorkspace(t)
+
+	bob, err := models.NewUser("bob", "correct horse")
+	if err != nil {
+		t.Fatalf("unable to create user: %v", err)
+	}
+	if err := db.CreateUser(ctx, bob); err != nil {
+		t.Fatalf("unable to store user: %v", err)
+	}
+	other, err := db.CreateWorkspace(ctx, "Office", bob.Id)
+	if err != nil {
+		t.Fatalf("unable to create workspace: %v", err)
+	}
+	otherCtx := WithWorkspace(ctx, other.Id)
+
+	if err := db.CreateEntity(ctx, models.NewEntity(models.EntityWithId("drill"), models.EntityWithName("Drill"))); err != nil {
+		t.Fatalf("unable to create entity: %v", err)
+	}
+	if err := db.CreateEntity(otherCtx, models.NewEntity(models.EntityWithId("drill"), models.EntityWithName("Drill"))); !errors.Is(err, ErrEntityExists) {
+		t.Errorf("expected ErrEntityExists for an id of another workspace, got %v", err)
+	}
+	if err := db.CreateEntity(ctx, models.NewEntity(models.EntityWithId("drill"), models.EntityWithName("Drill"))); !errors.Is(err, ErrEntityExists) {
+		t.Errorf("expected ErrEntityExists within the workspace, got %v", err)
+	}
+}
+
+func TestSqliteSearchMatchesWordsLiterally(t *testing.T) {
+	db, ctx := newSqliteWorkspace(t)
+
+	for _, e := range []*models.Entity{
+		models.NewEntity(models.EntityWithId("paint"), models.EntityWithName("Paint 100% acrylic")),
+		models.NewEntity(models.EntityWithId("cable"), models.EntityWithName("USB_C cable")),
+		models.NewEntity(models.EntityWithId("share"), models.EntityWithName(`Share \\nas`)),
+		models.NewEntity(models.EntityWithId("usbc"), models.EntityWithName("USB-C charger")),
+	} {
+		if err := db.CreateEntity(ctx, e); err != nil {
+			t.Fatalf("unable to create %s: %v", e.Id, err)
+		}
+	}
+
+	for query, want := range map[string]string{
+		"100%":  "paint",
+		"%":     "paint",
+		"usb_c": "cable",
+		`\\nas`: "share",
+	} {
+		hits, err := db.SearchEntities(ctx, query, 10)
+		if err != nil {
+			t.Fatalf("unable to search %q: %v", query, err)
+		}
+		if len(hits) != 1 || hits[0].Entity.Id != want {
+			ids := make([]string, 0, len(hits))
+			for _, h := range hits {
+				ids = append(ids, h.Entity.Id)
+			}
+			t.Errorf("search %q = %v, want only %s", query, ids, want)
+		}
+	}
+}
+
+func TestSqliteTagsAreOnlyCreatedWhenAllowed(t *testing.T) {
+	db, ctx := newSqliteWorkspace(t)
+
+	if err := db.CreateEntity(ctx, models.NewEntity(models.EntityWithId("drill"), models.EntityWithName("Drill"))); err != nil {
+		t.Fatalf("unable to create entity: %v", err)
+	}
+	if _, err := db.AttachTags(ctx, "drill", "tools"); err != nil {
+		t.Fatalf("unable to attach tags: %v", err)
+	}
+
+	restricted := WithoutTagCreation(ctx)
+	if _, err := db.AttachTags(restricted, "drill", "tools"); err != nil {
+		t.Errorf("unable to attach an existing tag: %v", err)
+	}
+	if _, err := db.AttachTags(restricted, "drill", "tools", "garden"); !errors.Is(err, ErrTagCreationDenied) {
+		t.Errorf("expected ErrTagCreationDenied for a new tag, got %v", err)
+	}
+	saw := models.NewEntity(models.EntityWithName("Saw"), models.EntityWithTags([]string{"garden"}))
+	if err := db.CreateEntity(restricted, saw); !errors.Is(err, ErrTagCreationDenied) {
+		t.Errorf("expected ErrTagCreationDenied when creating an entity with a new tag, got %v", err)
+	}
+
+	tags, err := db.QueryTags(ctx)
+	if err != nil || len(tags) != 1 {
+		t.Errorf("expected only the tools tag, got %v (%v)", tags, err)
+	}
+}
+
+func TestSqliteApiKeysFollowTheRoleOfTheirCreator(t *testing.T) {
+	db, ctx := newSqliteWorkspace(t)
+	workspaceId, _ := WorkspaceFromContext(ctx)
+
+	bob, err := models.NewUser("bob", "correct horse")
+	if err != nil {
+		t.Fatalf("unable to create user: %v", err)
+	}
+	if err := db.CreateUser(ctx, bob); err != nil {
+		t.Fatalf("unable to store user: %v", err)
+	}
+	if _, err := db.AddWorkspaceMember(ctx, workspaceId, "bob", models.RoleOwner); err != nil {
+		t.Fatalf("unable to add member: %v", err)
+	}
+
+	_, token, err := db.CreateApiKey(ctx, "scanner", models.ApiKeyScopeWrite, bob.Id, nil)
+	if err != nil {
+		t.Fatalf("unable to create key: %v", err)
+	}
+	key, err := db.QueryApiKeyByToken(ctx, token)
+	if err != nil || key.Scope != models.ApiKeyScopeWrite || key.Name != "scanner" || key.CreatedAt.IsZero() {
+		t.Fatalf("expected the write key, got %v (%v)", key, err)
+	}
+
+	if _, err := db.UpdateMemberRole(ctx, workspaceId, bob.Id, models.RoleViewer); err != nil {
+		t.Fatalf("unable to demote: %v", err)
+	}
+	key, err = db.QueryApiKeyByToken(ctx, token)
+	if err != nil || key.Scope != models.ApiKeyScopeRead {
+		t.Errorf("expected the key of a viewer to read only, got %v (%v)", key, err)
+	}
+
+	if err := db.RemoveWorkspaceMember(ctx, workspaceId, bob.Id); err != nil {
+		t.Fatalf("unable to remove member: %v", err)
+	}
+	if _, err := db.QueryApiKeyByToken(ctx, token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken once the creator left, got %v", err)
+	}
+	if keys, err := db.QueryApiKeys(ctx); err != nil || len(keys) != 0 {
+		t.Errorf("expected the keys of the creator to be revoked, got %v (%v)", keys, err)
+	}
+}
+
+func TestSqlitePurgeDeletedKeepsAncestorsOfEntitiesNotDue(t *testing.T) {
+	db, ctx := newSqliteWorkspace(t)
+
+	for _, e := range []*models.Entity{
+		models.NewEntity(models.EntityWithId("box"), models.EntityWithName("Box"), models.EntityWithTags([]string{"old"})),
+		models.NewEntity(models.EntityWithId("cable"), models.EntityWithName("Cable"), models.EntityWithParentId("box")),
+		models.NewEntity(models.EntityWithId("shelf"), models.EntityWithName("Shelf")),
+		models.NewEntity(models.EntityWithId("tray"), models.EntityWithName("Tray"), models.EntityWithParentId("shelf")),
+		models.NewEntity(models.EntityWithId("tool"), models.EntityWithName("Tool"), models.EntityWithParentId("tray")),
+		models.NewEntity(models.EntityWithId("garage"), models.EntityWithName("Garage")),
+		models.NewEntity(models.EntityWithId("rake"), models.EntityWithName("Rake"), models.EntityWithParentId("garage")),
+	} {
+		if err := db.CreateEntity(ctx, e); err != nil {
+			t.Fatalf("unable to create %s: %v", e.Id, err)
+		}
+	}
+
+	if err := db.DeleteEntity(ctx, "box", true); err != nil {
+		t.Fatalf("unable to delete: %v", err)
+	}
+	if err := db.DeleteEntity(ctx, "shelf", true); err != nil {
+		t.Fatalf("unable to delete: %v", err)
+	}
+	if err := db.DeleteEntity(ctx, "rake", false); err != nil {
+		t.Fatalf("unable to delete: %v", err)
+	}
+	// Everything is due but the tool, which was deleted after the cutoff
+	cutoff := time.Now().Add(time.Hour)
+	if err := db.db.Unscoped().Model(&models.Entity{}).Where("id = ?", "tool").UpdateColumn("deleted_at", cutoff.Add(time.Minute)).Error; err != nil {
+		t.Fatalf("unable to backdate: %v", err)
+	}
+
+	purged, err := db.PurgeDeleted(ctx, cutoff)
+	if err != nil {
+		t.Fatalf("unable to purge: %v", err)
+	}
+	ids := make([]string, 0, len(purged))
+	for _, e := range purged {
+		ids = append(ids, e.Id)
+	}
+	slices.Sort(ids)
+	if !slices.Equal(ids, []string{"box", "cable", "rake"}) {
+		t.Errorf("purged %v, want box, cable and rake", ids)
+	}
+
+	var left []string
+	if err := db.db.Unscoped().Model(&models.Entity{}).Order("id").Pluck("id", &left).Error; err != nil {
+		t.Fatalf("unable to list entities: %v", err)
+	}
+	if !slices.Equal(left, []string{"garage", "shelf", "tool", "tray"}) {
+		t.Errorf("kept %v, want garage, shelf, tool and tray", left)
+	}
+	var links int64
+	if err := db.db.Table("entity_tags").Count(&links).Error; err != nil || links != 0 {
+		t.Errorf("expected the tags of purged entities to be detached, got %d (%v)", links, err)
+	}
+}
+
+func TestSqliteRestoreCascadeOnlyRestoresWhatWasDeletedTogether(t *testing.T) {
+	db, ctx := newSqliteWorkspace(t)
+
+	for _, e := range []*models.Entity{
+		models.NewEntity(models.EntityWithId("box"), models.EntityWithName("Box")),
+		models.NewEntity(models.EntityWithId("cable"), models.EntityWithName("Cable"), models.EntityWithParentId("box")),
+		models.NewEntity(models.EntityWithId("plug"), models.EntityWithName("Plug"), models.EntityWithParentId("cable")),
+		models.NewEntity(models.EntityWithId("fuse"), models.EntityWithName("Fuse"), models.EntityWithParentId("box")),
+	} {
+		if err := db.CreateEntity(ctx, e); err != nil {
+			t.Fatalf("unable to create %s: %v", e.Id, err)
+		}
+	}
+
+	// The fuse was thrown away before the box
+	if err := db.DeleteEntity(ctx, "fuse", false); err != nil {
+		t.Fatalf("unable to delete: %v", err)
+	}
+	if err := db.DeleteEntity(ctx, "box", true); err != nil {
+		t.Fatalf("unable to delete: %v", err)
+	}
+
+	if _, err := db.RestoreEntity(ctx, "box", true); err != nil {
+		t.Fatalf("unable to restore: %v", err)
+	}
+
+	deleted, err := db.QueryDeleted(ctx)
+	if err != nil {
+		t.Fatalf("unable to query the trash: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].Id != "fuse" {
+		t.Errorf("expected only the fuse to stay in the trash, got %v", deleted)
+	}
+	for _, id := range []string{"box", "cable", "plug"} {
+		if _, err := db.QueryById(ctx, id); err != nil {
+			t.Errorf("expected %s to be restored, got %v", id, err)
+		}
+	}
+}
+
+func TestSqliteDuplicateTagNamesLosingARaceAreConflicts(t *testing.T) {
+	db, ctx := newSqliteWorkspace(t)
+	workspaceId, _ := WorkspaceFromContext(ctx)
+
+	if _, err := db.CreateTag(ctx, "tools"); err != nil {
+		t.Fatalf("unable to create tag: %v", err)
+	}
+
+	// What a request that passed ensureTagNameFree before the tag above was stored runs into
+	err := db.db.Create(&models.Tag{WorkspaceId: workspaceId, Name: "tools"}).Error
+	if !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Fatalf("expected the unique violation to be translated, got %v", err)
+	}
+	err = duplicateAs(err, func() error {
+		return ensureTagNameFree(db.db, workspaceId, "tools", "")
+	})
+	if !errors.Is(err, ErrTagExists) {
+		t.Errorf("expected ErrTagExists, got %v", err)
+	}
+}
+
+func TestSqliteDuplicateCodesLosingARaceAreConflicts(t *testing.T) {
+	db, ctx := newSqliteWorkspace(t)
+	workspaceId, _ := WorkspaceFromContext(ctx)
+
+	if err := db.CreateEntity(ctx, models.NewEntity(models.EntityWithName("Drill"), models.EntityWithCode("DRILL1"))); err != nil {
+		t.Fatalf("unable to create entity: %v", err)
+	}
+
+	// What a request that passed ensureCodeFree before the entity above was stored runs into
+	saw := models.NewEntity(models.EntityWithId("saw"), models.EntityWithName("Saw"), models.EntityWithCode("DRILL1"))
+	saw.WorkspaceId = workspaceId
+	err := db.db.Create(saw).Error
+	if !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Fatalf("expected the unique violation to be translated, got %v", err)
+	}
+	err = duplicateAs(err, func() error {
+		return ensureCodeFree(db.db, workspaceId, "DRILL1", "saw")
+	})
+	if !errors.Is(err, ErrCodeInUse) {
+		t.Errorf("expected ErrCodeInUse, got %v", err)
+	}
+}
+
+func TestSqliteQuerySubtreeReportsCutOffLevels(t *testing.T) {
+	db, ctx := newSqliteWorkspace(t)
+
+	for _, e := range []*models.Entity{
+		models.NewEntity(models.EntityWithId("room"), models.EntityWithName("Room")),
+		models.NewEntity(models.EntityWithId("shelf"), models.EntityWithName("Shelf"), models.EntityWithParentId("room")),
+		models.NewEntity(models.EntityWithId("box"), models.EntityWithName("Box"), models.EntityWithParentId("shelf")),
+	} {
+		if err := db.CreateEntity(ctx, e); err != nil {
+			t.Fatalf("unable to create %s: %v", e.Id, err)
+		}
+	}
+
+	for _, c := range []struct {
+		depth     int
+		nodes     int
+		loaded    int
+		truncated bool
+	}{
+		{2, 10, 3, false},
+		{1, 10, 2, true},
+		{2, 2, 2, true},
+	} {
+		tree, truncated, err := db.QuerySubtree(ctx, "room", c.depth, c.nodes)
+		if err != nil {
+			t.Fatalf("unable to query subtree: %v", err)
+		}
+		loaded := 0
+		var count func(e *models.Entity)
+		count = func(e *models.Entity) {
+			loaded++
+			for _, child := range e.Children {
+				count(child)
+			}
+		}
+		count(tree)
+		if loaded != c.loaded || truncated != c.truncated {
+			t.Errorf("depth %d and %d nodes loaded %d (truncated %v), want %d (truncated %v)",
+				c.depth, c.nodes, loaded, truncated, c.loaded, c.truncated)
+		}
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
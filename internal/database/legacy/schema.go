@@ -0,0 +1,180 @@
+// Package legacy
+// holds the models as they were when the schema was kept up to date by AutoMigrate. Databases
+// from that time are brought up to the baseline migration with them, so they must match
+// migrations/*/0001_initial.up.sql and never change, whatever becomes of the models.
+package legacy
+
+import (
+	"encoding/json"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"time"
+)
+
+// Tables lists every table of the baseline, in the order AutoMigrate creates them
+var Tables = []any{
+	&Workspace{},
+	&Entity{},
+	&Tag{},
+	&AttributeDefinition{},
+	&StockAdjustment{},
+	&EntityEvent{},
+	&Loan{},
+	&User{},
+	&AuthToken{},
+	&WorkspaceMember{},
+	&SubtreeGrant{},
+	&ApiKey{},
+}
+
+// WorkspaceTables hold rows that belong to a workspace
+var WorkspaceTables = []any{
+	&Entity{},
+	&Tag{},
+	&AttributeDefinition{},
+	&Loan{},
+	&EntityEvent{},
+}
+
+// StringList is stored as a text[] on Postgres and as a JSON array elsewhere
+type StringList []string
+
+func (StringList) GormDataType() string {
+	return "string_list"
+}
+
+func (StringList) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if db.Dialector.Name() == "postgres" {
+		return "text[]"
+	}
+	return "text"
+}
+
+type Workspace struct {
+	Id        string `gorm:"primaryKey"`
+	Name      string `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Entity struct {
+	Id          string    `gorm:"primaryKey"`
+	WorkspaceId string    `gorm:"index"`
+	ParentId    *string   `gorm:"index"`
+	Code        *string   `gorm:"uniqueIndex"`
+	Parent      *Entity   `gorm:"foreignKey:ParentId"`
+	Children    []*Entity `gorm:"foreignKey:ParentId"`
+	Name        string
+	Description string
+	Images      StringList
+	Tags        []*Tag         `gorm:"many2many:entity_tags"`
+	Attributes  map[string]any `gorm:"type:jsonb;serializer:json;not null;default:'{}'"`
+	Quantity    int64          `gorm:"not null;default:1;check:chk_entities_quantity,quantity >= 0"`
+	Unit        string
+	MinQuantity *int64 `gorm:"index"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+type Tag struct {
+	Id          string `gorm:"primaryKey"`
+	WorkspaceId string `gorm:"uniqueIndex:idx_tags_workspace_name,priority:1"`
+	Name        string `gorm:"uniqueIndex:idx_tags_workspace_name,priority:2;not null"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type AttributeDefinition struct {
+	WorkspaceId string   `gorm:"primaryKey"`
+	Key         string   `gorm:"primaryKey"`
+	Type        string   `gorm:"not null"`
+	Options     []string `gorm:"serializer:json"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type StockAdjustment struct {
+	Id        string `gorm:"primaryKey"`
+	EntityId  string `gorm:"index;not null"`
+	Delta     int64
+	Quantity  int64
+	Reason    string
+	CreatedAt time.Time
+}
+
+type EntityEvent struct {
+	Id          string `gorm:"primaryKey"`
+	WorkspaceId string `gorm:"index"`
+	EntityId    string `gorm:"index:idx_entity_events_entity_time,priority:1;not null"`
+	Action      string `gorm:"not null"`
+	Actor       string `gorm:"not null"`
+	ParentId    *string
+	Before      json.RawMessage `gorm:"type:jsonb;serializer:json"`
+	After       json.RawMessage `gorm:"type:jsonb;serializer:json"`
+	CreatedAt   time.Time       `gorm:"index:idx_entity_events_entity_time,priority:2"`
+}
+
+type Loan struct {
+	Id           string  `gorm:"primaryKey"`
+	WorkspaceId  string  `gorm:"index"`
+	EntityId     string  `gorm:"not null;index;uniqueIndex:idx_loans_active_entity,where:returned_at IS NULL"`
+	Entity       *Entity `gorm:"foreignKey:EntityId"`
+	Borrower     string  `gorm:"not null"`
+	Note         string
+	DueAt        *time.Time `gorm:"index"`
+	CheckedOutBy string
+	CheckedInBy  *string
+	CheckedOutAt time.Time  `gorm:"autoCreateTime"`
+	ReturnedAt   *time.Time `gorm:"index"`
+}
+
+type User struct {
+	Id           string `gorm:"primaryKey"`
+	Username     string `gorm:"uniqueIndex;not null"`
+	PasswordHash string `gorm:"not null"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type AuthToken struct {
+	Id        string    `gorm:"primaryKey"`
+	UserId    string    `gorm:"index;not null"`
+	User      *User     `gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
+	TokenHash string    `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	CreatedAt time.Time
+}
+
+type WorkspaceMember struct {
+	WorkspaceId string     `gorm:"primaryKey"`
+	UserId      string     `gorm:"primaryKey;index"`
+	Workspace   *Workspace `gorm:"foreignKey:WorkspaceId;constraint:OnDelete:CASCADE"`
+	User        *User      `gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
+	Role        string     `gorm:"not null;default:viewer"`
+	CreatedAt   time.Time
+}
+
+type SubtreeGrant struct {
+	Id          string `gorm:"primaryKey"`
+	WorkspaceId string `gorm:"index;not null"`
+	UserId      string `gorm:"uniqueIndex:idx_subtree_grants_user_entity;not null"`
+	User        *User  `gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
+	EntityId    string `gorm:"uniqueIndex:idx_subtree_grants_user_entity;index;not null"`
+	Role        string `gorm:"not null"`
+	CreatedAt   time.Time
+}
+
+type ApiKey struct {
+	Id          string     `gorm:"primaryKey"`
+	WorkspaceId string     `gorm:"index;not null"`
+	Workspace   *Workspace `gorm:"foreignKey:WorkspaceId;constraint:OnDelete:CASCADE"`
+	Name        string     `gorm:"not null"`
+	Hint        string     `gorm:"not null"`
+	KeyHash     string     `gorm:"uniqueIndex;not null"`
+	Scope       string     `gorm:"not null"`
+	CreatedBy   string
+	ExpiresAt   *time.Time
+	LastUsedAt  *time.Time
+	CreatedAt   time.Time
+}
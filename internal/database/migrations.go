@@ -0,0 +1,324 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migrations are SQL files named <version>_<name>.up.sql and <version>_<name>.down.sql,
+// one directory per dialect. Versions are applied in ascending order and every version
+// needs both files. Applied migrations are never edited, changes go into a new version.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// baselineVersion is the schema that AutoMigrate produced before migrations were versioned
+const baselineVersion = 1
+
+// migrationLockKey identifies the advisory lock taken while a migration runs
+const migrationLockKey int64 = 0x74746d67
+
+// Migration
+// is an embedded schema change and whether it has been applied to the database.
+type Migration struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time // nil while pending
+	Unknown   bool       // Applied by a newer binary, this one has no files for it
+
+	up   string
+	down string
+}
+
+// schemaMigration is the record of an applied migration
+type schemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+////////////////////////////////////////////////
+// Migration Methods
+////////////////////////////////////////////////
+
+// Migrate
+// applies all pending migrations, each in its own transaction. Databases created before
+// migrations were versioned are brought up to the baseline and recorded at it first.
+// Fails with ErrSchemaTooNew, without changing anything, when the database has migrations
+// applied that this binary does not know.
+func (g *GormAdapter) Migrate(ctx context.Context) error {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return err
+	}
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := dialectOf(tx).lock(tx, migrationLockKey); err != nil {
+			return err
+		}
+		if tx.Migrator().HasTable(&schemaMigration{}) {
+			return nil
+		}
+		return tx.Migrator().CreateTable(&schemaMigration{})
+	}); err != nil {
+		return err
+	}
+
+	migrations, err := g.QueryMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	if err := ensureSchemaKnown(migrations); err != nil {
+		return err
+	}
+
+	if migrations[0].AppliedAt == nil && g.db.WithContext(ctx).Migrator().HasTable("entities") {
+		if err := g.adoptLegacySchema(ctx, migrations[0]); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range migrations {
+		if m.AppliedAt != nil {
+			continue
+		}
+		if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			applied, err := lockMigrations(tx, m.Version)
+			if err != nil || applied {
+				return err // Another instance applied it in the meantime
+			}
+			if err := tx.Exec(m.up).Error; err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+			}
+			return tx.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RollbackMigration
+// reverts the latest applied migration and returns it. Fails with ErrNoMigrationApplied
+// on an empty database and with ErrSchemaTooNew when the latest one is unknown to this binary.
+// Reverting the baseline drops every table, it fails with ErrBaselineRollback unless forced.
+func (g *GormAdapter) RollbackMigration(ctx context.Context, force bool) (*Migration, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	migrations, err := g.QueryMigrations(ctx)
+	if errors.Is(err, ErrNoMigrationsTable) {
+		return nil, ErrNoMigrationApplied
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureSchemaKnown(migrations); err != nil {
+		return nil, err
+	}
+
+	var latest *Migration
+	for _, m := range migrations {
+		if m.AppliedAt != nil {
+			latest = m
+		}
+	}
+	if latest == nil {
+		return nil, ErrNoMigrationApplied
+	}
+	if latest.Version == baselineVersion && !force {
+		return nil, ErrBaselineRollback
+	}
+
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		applied, err := lockMigrations(tx, latest.Version)
+		if err != nil {
+			return err
+		}
+		if !applied {
+			return ErrNoMigrationApplied
+		}
+		if err := tx.Exec(latest.down).Error; err != nil {
+			return fmt.Errorf("rollback of %d_%s failed: %w", latest.Version, latest.Name, err)
+		}
+		return tx.Delete(&schemaMigration{}, "version = ?", latest.Version).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	latest.AppliedAt = nil
+	return latest, nil
+}
+
+// QueryMigrations
+// returns the migrations of this binary in order, followed by the ones the database
+// has applied that this binary does not know. It only reads, a database that has never
+// been migrated fails with ErrNoMigrationsTable.
+func (g *GormAdapter) QueryMigrations(ctx context.Context) ([]*Migration, error) {
+	if err := g.ensureDbConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	migrations, err := loadMigrations(dialectOf(g.db).name())
+	if err != nil {
+		return nil, err
+	}
+
+	if !g.db.WithContext(ctx).Migrator().HasTable(&schemaMigration{}) {
+		return nil, ErrNoMigrationsTable
+	}
+
+	var applied []*schemaMigration
+	if err := g.db.
+		WithContext(ctx).
+		Order("version").
+		Find(&applied).
+		Error; err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration, len(migrations))
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+	for _, a := range applied {
+		appliedAt := a.AppliedAt
+		if m, ok := byVersion[a.Version]; ok {
+			m.AppliedAt = &appliedAt
+			continue
+		}
+		migrations = append(migrations, &Migration{
+			Version:   a.Version,
+			Name:      a.Name,
+			AppliedAt: &appliedAt,
+			Unknown:   true,
+		})
+	}
+
+	return migrations, nil
+}
+
+////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////
+
+// loadMigrations
+// reads the embedded migrations of the dialect, ordered by version.
+func loadMigrations(dialect string) ([]*Migration, error) {
+	dir := path.Join("migrations", dialect)
+	files, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for the %s dialect: %w", dialect, err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, f := range files {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(f.Name(), ".sql"), ".")
+		prefix, name, hasName := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || !hasName || err != nil || version < 1 || !strings.HasSuffix(f.Name(), ".sql") {
+			return nil, fmt.Errorf("malformed migration file name %s", f.Name())
+		}
+
+		content, err := fs.ReadFile(migrationFiles, path.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("migration %d has the names %s and %s", version, m.Name, name)
+		}
+		switch direction {
+		case "up":
+			m.up = string(content)
+		case "down":
+			m.down = string(content)
+		default:
+			return nil, fmt.Errorf("malformed migration file name %s", f.Name())
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	if len(migrations) == 0 || migrations[0].Version != baselineVersion {
+		return nil, fmt.Errorf("the %s migrations have to start at version %d", dialect, baselineVersion)
+	}
+
+	return migrations, nil
+}
+
+func ensureSchemaKnown(migrations []*Migration) error {
+	for _, m := range migrations {
+		if m.Unknown {
+			return fmt.Errorf("%w, version %d_%s is applied", ErrSchemaTooNew, m.Version, m.Name)
+		}
+	}
+	return nil
+}
+
+// lockMigrations
+// serializes migrations of concurrently starting instances and reports whether the version
+// has been applied by now. SQLite transactions take the write lock up front instead.
+func lockMigrations(tx *gorm.DB, version int) (bool, error) {
+	if err := dialectOf(tx).lock(tx, migrationLockKey); err != nil {
+		return false, err
+	}
+
+	var count int64
+	if err := tx.
+		Model(&schemaMigration{}).
+		Where("version = ?", version).
+		Count(&count).
+		Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// adoptLegacySchema
+// brings a database created by AutoMigrate up to the baseline and records it there, in one
+// transaction under the migration lock so that concurrently starting instances adopt it once.
+func (g *GormAdapter) adoptLegacySchema(ctx context.Context, baseline *Migration) error {
+	now := time.Now()
+	if err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		applied, err := lockMigrations(tx, baseline.Version)
+		if err != nil || applied {
+			return err // Another instance adopted it in the meantime
+		}
+		if err := migrateLegacy(tx); err != nil {
+			return fmt.Errorf("unable to bring the existing schema up to the baseline: %w", err)
+		}
+		return tx.Create(&schemaMigration{Version: baseline.Version, Name: baseline.Name, AppliedAt: now}).Error
+	}); err != nil {
+		return err
+	}
+	baseline.AppliedAt = &now
+	return nil
+}
@@ -0,0 +1,167 @@
+-- Schema as of the switch from AutoMigrate to versioned migrations.
+-- Databases created before the switch are recorded at this version without running it.
+
+CREATE TABLE workspaces (
+	id text PRIMARY KEY,
+	name text NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz
+);
+
+CREATE TABLE entities (
+	id text PRIMARY KEY,
+	workspace_id text,
+	parent_id text,
+	code text,
+	name text,
+	description text,
+	images text[],
+	attributes jsonb NOT NULL DEFAULT '{}',
+	quantity bigint NOT NULL DEFAULT 1,
+	unit text,
+	min_quantity bigint,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	CONSTRAINT fk_entities_children FOREIGN KEY (parent_id) REFERENCES entities (id),
+	CONSTRAINT chk_entities_quantity CHECK (quantity >= 0)
+);
+CREATE INDEX idx_entities_workspace_id ON entities (workspace_id);
+CREATE INDEX idx_entities_parent_id ON entities (parent_id);
+CREATE UNIQUE INDEX idx_entities_code ON entities (code);
+CREATE INDEX idx_entities_min_quantity ON entities (min_quantity);
+CREATE INDEX idx_entities_deleted_at ON entities (deleted_at);
+-- Backs SearchEntities, the expression has to match searchDocument for the index to be used
+CREATE INDEX idx_entities_search ON entities
+	USING GIN (to_tsvector('english', coalesce(name, '') || ' ' || coalesce(description, '')));
+
+CREATE TABLE tags (
+	id text PRIMARY KEY,
+	workspace_id text,
+	name text NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz
+);
+CREATE UNIQUE INDEX idx_tags_workspace_name ON tags (workspace_id, name);
+
+CREATE TABLE entity_tags (
+	entity_id text,
+	tag_id text,
+	PRIMARY KEY (entity_id, tag_id),
+	CONSTRAINT fk_entity_tags_entity FOREIGN KEY (entity_id) REFERENCES entities (id),
+	CONSTRAINT fk_entity_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id)
+);
+
+CREATE TABLE attribute_definitions (
+	workspace_id text,
+	key text,
+	type text NOT NULL,
+	options text,
+	created_at timestamptz,
+	updated_at timestamptz,
+	PRIMARY KEY (workspace_id, key)
+);
+
+CREATE TABLE stock_adjustments (
+	id text PRIMARY KEY,
+	entity_id text NOT NULL,
+	delta bigint,
+	quantity bigint,
+	reason text,
+	created_at timestamptz
+);
+CREATE INDEX idx_stock_adjustments_entity_id ON stock_adjustments (entity_id);
+
+CREATE TABLE entity_events (
+	id text PRIMARY KEY,
+	workspace_id text,
+	entity_id text NOT NULL,
+	action text NOT NULL,
+	actor text NOT NULL,
+	parent_id text,
+	before jsonb,
+	after jsonb,
+	created_at timestamptz
+);
+CREATE INDEX idx_entity_events_workspace_id ON entity_events (workspace_id);
+CREATE INDEX idx_entity_events_entity_time ON entity_events (entity_id, created_at);
+
+CREATE TABLE loans (
+	id text PRIMARY KEY,
+	workspace_id text,
+	entity_id text NOT NULL,
+	borrower text NOT NULL,
+	note text,
+	due_at timestamptz,
+	checked_out_by text,
+	checked_in_by text,
+	checked_out_at timestamptz,
+	returned_at timestamptz,
+	CONSTRAINT fk_loans_entity FOREIGN KEY (entity_id) REFERENCES entities (id)
+);
+CREATE INDEX idx_loans_workspace_id ON loans (workspace_id);
+CREATE INDEX idx_loans_entity_id ON loans (entity_id);
+CREATE UNIQUE INDEX idx_loans_active_entity ON loans (entity_id) WHERE returned_at IS NULL;
+CREATE INDEX idx_loans_due_at ON loans (due_at);
+CREATE INDEX idx_loans_returned_at ON loans (returned_at);
+
+CREATE TABLE users (
+	id text PRIMARY KEY,
+	username text NOT NULL,
+	password_hash text NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz
+);
+CREATE UNIQUE INDEX idx_users_username ON users (username);
+
+CREATE TABLE auth_tokens (
+	id text PRIMARY KEY,
+	user_id text NOT NULL,
+	token_hash text NOT NULL,
+	expires_at timestamptz NOT NULL,
+	created_at timestamptz,
+	CONSTRAINT fk_auth_tokens_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_auth_tokens_user_id ON auth_tokens (user_id);
+CREATE UNIQUE INDEX idx_auth_tokens_token_hash ON auth_tokens (token_hash);
+CREATE INDEX idx_auth_tokens_expires_at ON auth_tokens (expires_at);
+
+CREATE TABLE workspace_members (
+	workspace_id text,
+	user_id text,
+	role text NOT NULL DEFAULT 'viewer',
+	created_at timestamptz,
+	PRIMARY KEY (workspace_id, user_id),
+	CONSTRAINT fk_workspace_members_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces (id) ON DELETE CASCADE,
+	CONSTRAINT fk_workspace_members_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_workspace_members_user_id ON workspace_members (user_id);
+
+CREATE TABLE subtree_grants (
+	id text PRIMARY KEY,
+	workspace_id text NOT NULL,
+	user_id text NOT NULL,
+	entity_id text NOT NULL,
+	role text NOT NULL,
+	created_at timestamptz,
+	CONSTRAINT fk_subtree_grants_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_subtree_grants_workspace_id ON subtree_grants (workspace_id);
+CREATE INDEX idx_subtree_grants_entity_id ON subtree_grants (entity_id);
+CREATE UNIQUE INDEX idx_subtree_grants_user_entity ON subtree_grants (user_id, entity_id);
+
+CREATE TABLE api_keys (
+	id text PRIMARY KEY,
+	workspace_id text NOT NULL,
+	name text NOT NULL,
+	hint text NOT NULL,
+	key_hash text NOT NULL,
+	scope text NOT NULL,
+	created_by text,
+	expires_at timestamptz,
+	last_used_at timestamptz,
+	created_at timestamptz,
+	CONSTRAINT fk_api_keys_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_api_keys_workspace_id ON api_keys (workspace_id);
+CREATE UNIQUE INDEX idx_api_keys_key_hash ON api_keys (key_hash);
@@ -0,0 +1,3 @@
+-- Fails when two workspaces hold the same code
+DROP INDEX idx_entities_workspace_code;
+CREATE UNIQUE INDEX idx_entities_code ON entities (code);
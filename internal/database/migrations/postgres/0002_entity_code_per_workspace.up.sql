@@ -0,0 +1,3 @@
+-- Codes are unique per workspace, so that an archive can be imported next to its original
+DROP INDEX idx_entities_code;
+CREATE UNIQUE INDEX idx_entities_workspace_code ON entities (workspace_id, code);
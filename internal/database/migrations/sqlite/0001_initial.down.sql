@@ -0,0 +1,13 @@
+DROP TABLE api_keys;
+DROP TABLE subtree_grants;
+DROP TABLE workspace_members;
+DROP TABLE auth_tokens;
+DROP TABLE users;
+DROP TABLE loans;
+DROP TABLE entity_events;
+DROP TABLE stock_adjustments;
+DROP TABLE attribute_definitions;
+DROP TABLE entity_tags;
+DROP TABLE tags;
+DROP TABLE entities;
+DROP TABLE workspaces;
@@ -0,0 +1,216 @@
+package database
+
+import (
+	"Backend/internal/database/legacy"
+	"Backend/internal/models"
+	"encoding/json"
+	"errors"
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+// migrationActor is recorded for the history written for entities that predate it
+const migrationActor = "migration"
+
+// migrateLegacy
+// is how the schema was kept up to date before migrations were versioned. It only runs on
+// databases from that time, to bring them up to the baseline migration. It works on the
+// frozen models of the legacy package and must not change.
+func migrateLegacy(tx *gorm.DB) error {
+	// Attribute keys used to be unique on their own, they are unique per workspace now.
+	// Only Postgres databases predate workspaces, the statement is written for it.
+	migrator := tx.Migrator()
+	if dialectOf(tx).name() == dialectPostgres &&
+		migrator.HasTable(&legacy.AttributeDefinition{}) &&
+		!migrator.HasColumn(&legacy.AttributeDefinition{}, "WorkspaceId") {
+		if err := tx.
+			Exec(`
+				ALTER TABLE attribute_definitions
+				ADD COLUMN workspace_id text NOT NULL DEFAULT '',
+				DROP CONSTRAINT attribute_definitions_pkey,
+				ADD PRIMARY KEY (workspace_id, key)`).
+			Error; err != nil {
+			return err
+		}
+	}
+	// Members from before roles existed had full access, they keep it as owners
+	promoteMembers := migrator.HasTable(&legacy.WorkspaceMember{}) &&
+		!migrator.HasColumn(&legacy.WorkspaceMember{}, "Role")
+	if migrator.HasIndex(&legacy.Tag{}, "idx_tags_name") {
+		if err := migrator.DropIndex(&legacy.Tag{}, "idx_tags_name"); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.AutoMigrate(legacy.Tables...); err != nil {
+		return err
+	}
+
+	if promoteMembers {
+		if err := tx.
+			Model(&legacy.WorkspaceMember{}).
+			Where("1 = 1").
+			Update("role", models.RoleOwner).
+			Error; err != nil {
+			return err
+		}
+	}
+
+	// Entities stored before codes existed get one, so that every entity can be resolved
+	var uncoded []string
+	if err := tx.
+		Model(&legacy.Entity{}).
+		Unscoped().
+		Where("code IS NULL").
+		Pluck("id", &uncoded).
+		Error; err != nil {
+		return err
+	}
+	for _, id := range uncoded {
+		if err := tx.
+			Model(&legacy.Entity{}).
+			Unscoped().
+			Where("id = ?", id).
+			UpdateColumn("code", models.NewCode()).
+			Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Transaction(assignDefaultWorkspace); err != nil {
+		return err
+	}
+
+	if err := tx.Transaction(backfillHistory); err != nil {
+		return err
+	}
+
+	// Same index as in the baseline migration
+	if dialectOf(tx).name() == dialectPostgres {
+		if err := tx.
+			Exec(searchIndexDdl).
+			Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// assignDefaultWorkspace
+// moves rows stored before workspaces existed into the default workspace, the oldest one,
+// and makes users without any workspace a member of it, so that nothing is lost on the upgrade.
+func assignDefaultWorkspace(tx *gorm.DB) error {
+	var orphaned bool
+	for _, model := range legacy.WorkspaceTables {
+		var count int64
+		if err := tx.
+			Unscoped().
+			Model(model).
+			Where("workspace_id IS NULL OR workspace_id = ''").
+			Count(&count).
+			Error; err != nil {
+			return err
+		}
+		orphaned = orphaned || count > 0
+	}
+
+	var homeless []string
+	if err := tx.
+		Model(&legacy.User{}).
+		Where("NOT EXISTS (SELECT 1 FROM workspace_members WHERE workspace_members.user_id = users.id)").
+		Pluck("id", &homeless).
+		Error; err != nil {
+		return err
+	}
+
+	if !orphaned && len(homeless) == 0 {
+		return nil
+	}
+
+	var workspace legacy.Workspace
+	if err := tx.
+		Order("created_at").
+		First(&workspace).
+		Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		workspace = legacy.Workspace{Id: cuid.New(), Name: models.DefaultWorkspaceName}
+		if err := tx.Create(&workspace).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, model := range legacy.WorkspaceTables {
+		if err := tx.
+			Unscoped().
+			Model(model).
+			Where("workspace_id IS NULL OR workspace_id = ''").
+			UpdateColumn("workspace_id", workspace.Id).
+			Error; err != nil {
+			return err
+		}
+	}
+
+	for _, userId := range homeless {
+		if err := tx.Create(&legacy.WorkspaceMember{
+			WorkspaceId: workspace.Id,
+			UserId:      userId,
+			Role:        string(models.RoleOwner),
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backfillHistory
+// gives entities stored before the history existed a create event, and a delete event
+// when they are in the trash, so that location queries have something to answer with.
+// The snapshots are written in the current format, which is what the history is read with.
+func backfillHistory(tx *gorm.DB) error {
+	var entities []*models.Entity
+	if err := tx.
+		Unscoped().
+		Preload("Tags").
+		Where("NOT EXISTS (SELECT 1 FROM entity_events WHERE entity_events.entity_id = entities.id)").
+		Find(&entities).
+		Error; err != nil {
+		return err
+	}
+
+	for _, e := range entities {
+		snapshot, err := json.Marshal(models.NewEntitySnapshot(e))
+		if err != nil {
+			return err
+		}
+		events := []*legacy.EntityEvent{{
+			Id:          cuid.New(),
+			WorkspaceId: e.WorkspaceId,
+			EntityId:    e.Id,
+			Action:      string(models.EntityActionCreate),
+			Actor:       migrationActor,
+			ParentId:    e.ParentId,
+			After:       snapshot,
+			CreatedAt:   e.CreatedAt,
+		}}
+		if e.DeletedAt.Valid {
+			events = append(events, &legacy.EntityEvent{
+				Id:          cuid.New(),
+				WorkspaceId: e.WorkspaceId,
+				EntityId:    e.Id,
+				Action:      string(models.EntityActionDelete),
+				Actor:       migrationActor,
+				Before:      snapshot,
+				CreatedAt:   e.DeletedAt.Time,
+			})
+		}
+		if err := tx.Create(events).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,221 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrationsOfDialectsMatch(t *testing.T) {
+	pg, err := loadMigrations(dialectPostgres)
+	if err != nil {
+		t.Fatalf("unable to load postgres migrations: %v", err)
+	}
+	lite, err := loadMigrations(dialectSqlite)
+	if err != nil {
+		t.Fatalf("unable to load sqlite migrations: %v", err)
+	}
+
+	if len(pg) != len(lite) {
+		t.Fatalf("postgres has %d migrations, sqlite %d", len(pg), len(lite))
+	}
+	for i := range pg {
+		if pg[i].Version != lite[i].Version || pg[i].Name != lite[i].Name {
+			t.Errorf("migration %d is %d_%s on postgres and %d_%s on sqlite",
+				i, pg[i].Version, pg[i].Name, lite[i].Version, lite[i].Name)
+		}
+	}
+}
+
+func TestSqliteMigrateUpDown(t *testing.T) {
+	db, err := CreateGormSqliteAdapter(filepath.Join(t.TempDir(), "tt.db"))
+	if err != nil {
+		t.Fatalf("unable to create adapter: %v", err)
+	}
+	ctx := context.Background()
+	t.Cleanup(func() { _ = db.Disconnect(ctx) })
+
+	if _, err := db.RollbackMigration(ctx, false); !errors.Is(err, ErrNoMigrationApplied) {
+		t.Fatalf("rollback of an empty database = %v, want ErrNoMigrationApplied", err)
+	}
+	if _, err := db.QueryMigrations(ctx); !errors.Is(err, ErrNoMigrationsTable) {
+		t.Fatalf("migrations of an empty database = %v, want ErrNoMigrationsTable", err)
+	}
+	if db.db.Migrator().HasTable(&schemaMigration{}) {
+		t.Fatal("querying the migrations created the migrations table")
+	}
+
+	if err := db.Migrate(ctx); err != nil {
+		t.Fatalf("unable to migrate: %v", err)
+	}
+	// A second run has nothing left to do
+	if err := db.Migrate(ctx); err != nil {
+		t.Fatalf("unable to migrate again: %v", err)
+	}
+
+	migrations, err := db.QueryMigrations(ctx)
+	if err != nil {
+		t.Fatalf("unable to query migrations: %v", err)
+	}
+	for _, m := range migrations {
+		if m.AppliedAt == nil {
+			t.Errorf("migration %d_%s is pending after Migrate", m.Version, m.Name)
+		}
+	}
+
+	for range migrations[1:] {
+		if _, err := db.RollbackMigration(ctx, false); err != nil {
+			t.Fatalf("unable to roll back: %v", err)
+		}
+	}
+	if _, err := db.RollbackMigration(ctx, false); !errors.Is(err, ErrBaselineRollback) {
+		t.Fatalf("rollback of the baseline without force = %v, want ErrBaselineRollback", err)
+	}
+	if !db.db.Migrator().HasTable("entities") {
+		t.Fatal("entities are gone after a refused rollback")
+	}
+	if _, err := db.RollbackMigration(ctx, true); err != nil {
+		t.Fatalf("unable to roll back the baseline: %v", err)
+	}
+	if db.db.Migrator().HasTable("entities") {
+		t.Error("entities still exist after rolling back every migration")
+	}
+
+	if err := db.Migrate(ctx); err != nil {
+		t.Fatalf("unable to migrate after rolling back: %v", err)
+	}
+
+	// A newer binary applied a migration this one does not know
+	if err := db.db.Create(&schemaMigration{Version: 9999, Name: "future", AppliedAt: time.Now()}).Error; err != nil {
+		t.Fatalf("unable to record migration: %v", err)
+	}
+	if err := db.Migrate(ctx); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Migrate on a newer schema = %v, want ErrSchemaTooNew", err)
+	}
+	if _, err := db.RollbackMigration(ctx, true); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("RollbackMigration on a newer schema = %v, want ErrSchemaTooNew", err)
+	}
+}
+
+func TestSqliteMigrateAdoptsLegacySchema(t *testing.T) {
+	db, err := CreateGormSqliteAdapter(filepath.Join(t.TempDir(), "tt.db"))
+	if err != nil {
+		t.Fatalf("unable to create adapter: %v", err)
+	}
+	ctx := context.Background()
+	t.Cleanup(func() { _ = db.Disconnect(ctx) })
+
+	if err := db.ensureDbConnection(ctx); err != nil {
+		t.Fatalf("unable to connect: %v", err)
+	}
+	if err := migrateLegacy(db.db.WithContext(ctx)); err != nil {
+		t.Fatalf("unable to create the legacy schema: %v", err)
+	}
+
+	if err := db.Migrate(ctx); err != nil {
+		t.Fatalf("unable to migrate a legacy database: %v", err)
+	}
+
+	migrations, err := db.QueryMigrations(ctx)
+	if err != nil {
+		t.Fatalf("unable to query migrations: %v", err)
+	}
+	if migrations[0].AppliedAt == nil {
+		t.Error("the baseline is not recorded for a legacy database")
+	}
+}
+
+func TestSqliteLegacySchemaAdoptedByAnotherInstanceIsSkipped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tt.db")
+	ctx := context.Background()
+
+	adapters := make([]*GormAdapter, 2)
+	for i := range adapters {
+		db, err := CreateGormSqliteAdapter(path)
+		if err != nil {
+			t.Fatalf("unable to create adapter: %v", err)
+		}
+		if err := db.ensureDbConnection(ctx); err != nil {
+			t.Fatalf("unable to connect: %v", err)
+		}
+		t.Cleanup(func() { _ = db.Disconnect(ctx) })
+		adapters[i] = db
+	}
+	if err := migrateLegacy(adapters[0].db.WithContext(ctx)); err != nil {
+		t.Fatalf("unable to create the legacy schema: %v", err)
+	}
+	if err := adapters[0].db.Migrator().CreateTable(&schemaMigration{}); err != nil {
+		t.Fatalf("unable to create the migrations table: %v", err)
+	}
+
+	// Both instances find the baseline pending before either of them adopts the schema
+	stale, err := adapters[1].QueryMigrations(ctx)
+	if err != nil {
+		t.Fatalf("unable to query migrations: %v", err)
+	}
+	if err := adapters[0].Migrate(ctx); err != nil {
+		t.Fatalf("unable to migrate a legacy database: %v", err)
+	}
+	if err := adapters[1].adoptLegacySchema(ctx, stale[0]); err != nil {
+		t.Errorf("unable to adopt a legacy schema another instance adopted already: %v", err)
+	}
+
+	var baselines int64
+	if err := adapters[0].db.Model(&schemaMigration{}).Where("version = ?", baselineVersion).Count(&baselines).Error; err != nil {
+		t.Fatalf("unable to count migrations: %v", err)
+	}
+	if baselines != 1 {
+		t.Errorf("expected the baseline to be recorded once, got %d", baselines)
+	}
+}
+
+func TestSqliteLegacySchemaMatchesBaseline(t *testing.T) {
+	ctx := context.Background()
+	schemaOf := func(create func(db *GormAdapter) error) []string {
+		db, err := CreateGormSqliteAdapter(filepath.Join(t.TempDir(), "tt.db"))
+		if err != nil {
+			t.Fatalf("unable to create adapter: %v", err)
+		}
+		t.Cleanup(func() { _ = db.Disconnect(ctx) })
+		if err := db.ensureDbConnection(ctx); err != nil {
+			t.Fatalf("unable to connect: %v", err)
+		}
+		if err := create(db); err != nil {
+			t.Fatalf("unable to create the schema: %v", err)
+		}
+
+		var schema []string
+		if err := db.db.Raw(`
+			SELECT m.name || '.' || c.name || ' ' || c.type || ' ' || c."notnull" || ' ' || c.pk || ' ' ||
+				replace(coalesce(c.dflt_value, ''), '"', '''')
+			FROM sqlite_master m, pragma_table_info(m.name) c
+			WHERE m.type = 'table' AND m.name <> 'schema_migrations'
+			UNION ALL
+			SELECT name FROM sqlite_master WHERE type = 'index' AND name NOT LIKE 'sqlite_%'
+			ORDER BY 1`).
+			Scan(&schema).
+			Error; err != nil {
+			t.Fatalf("unable to read the schema: %v", err)
+		}
+		return schema
+	}
+
+	baseline := schemaOf(func(db *GormAdapter) error {
+		migrations, err := loadMigrations(dialectSqlite)
+		if err != nil {
+			return err
+		}
+		return db.db.Exec(migrations[0].up).Error
+	})
+	adopted := schemaOf(func(db *GormAdapter) error {
+		return migrateLegacy(db.db.WithContext(ctx))
+	})
+
+	if strings.Join(baseline, "\n") != strings.Join(adopted, "\n") {
+		t.Errorf("the legacy schema differs from the baseline\nbaseline:\n%s\nlegacy:\n%s",
+			strings.Join(baseline, "\n"), strings.Join(adopted, "\n"))
+	}
+}
@@ -0,0 +1,168 @@
+package database
+
+import (
+	"Backend/internal/models"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+type SortField string
+
+const (
+	SortByName      SortField = "name"
+	SortByCreatedAt SortField = "created_at"
+	SortByUpdatedAt SortField = "updated_at"
+)
+
+func SortFieldFromString(s string) (SortField, error) {
+	switch f := SortField(s); f {
+	case SortByName, SortByCreatedAt, SortByUpdatedAt:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported sort field: %v", s)
+	}
+}
+
+// PageRequest
+// describes a page of entities. A Limit of 0 returns every matching entity in order.
+type PageRequest struct {
+	Limit  int
+	Cursor string
+	Sort   SortField
+	Desc   bool
+}
+
+type Page struct {
+	Entities   []*models.Entity `json:"entities"`
+	NextCursor *string          `json:"next_cursor"`
+}
+
+// cursor is the position after the last entity of a page, it is bound to the sort it was
+// issued for so that it cannot be replayed against a different ordering.
+type cursor struct {
+	Sort  SortField `json:"s"`
+	Desc  bool      `json:"d"`
+	Value string    `json:"v"`
+	Id    string    `json:"i"`
+}
+
+func (c *cursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// sortValue returns the value of the sort column of the entity as stored in a cursor
+func sortValue(sort SortField, e *models.Entity) string {
+	switch sort {
+	case SortByCreatedAt:
+		return e.CreatedAt.Format(time.RFC3339Nano)
+	case SortByUpdatedAt:
+		return e.UpdatedAt.Format(time.RFC3339Nano)
+	default:
+		return e.Name
+	}
+}
+
+// columnValue converts a cursor value back to the type of the sort column
+func columnValue(sort SortField, value string) (any, error) {
+	switch sort {
+	case SortByCreatedAt, SortByUpdatedAt:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return t, nil
+	default:
+		return value, nil
+	}
+}
+
+// paginate
+// orders the query by the sort column with the id as tie-breaker, continues after the
+// cursor and fetches one extra row so that newPage can tell whether there is a next page.
+func paginate(tx *gorm.DB, page PageRequest) (*gorm.DB, error) {
+	if page.Sort == "" {
+		page.Sort = SortByCreatedAt
+	}
+	if _, err := SortFieldFromString(string(page.Sort)); err != nil {
+		return nil, err
+	}
+
+	direction, comparison := "ASC", ">"
+	if page.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if page.Cursor != "" {
+		c, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if c.Sort != page.Sort || c.Desc != page.Desc {
+			return nil, ErrInvalidCursor
+		}
+
+		value, err := columnValue(c.Sort, c.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		tx = tx.Where(
+			fmt.Sprintf("(entities.%s, entities.id) %s (?, ?)", page.Sort, comparison),
+			value,
+			c.Id,
+		)
+	}
+
+	tx = tx.Order(fmt.Sprintf("entities.%s %s, entities.id %s", page.Sort, direction, direction))
+
+	if page.Limit > 0 {
+		tx = tx.Limit(page.Limit + 1)
+	}
+
+	return tx, nil
+}
+
+// newPage
+// trims the extra row fetched by paginate and derives the cursor of the next page from it.
+func newPage(entities []*models.Entity, page PageRequest) *Page {
+	if page.Limit <= 0 || len(entities) <= page.Limit {
+		return &Page{Entities: entities}
+	}
+
+	if page.Sort == "" {
+		page.Sort = SortByCreatedAt
+	}
+
+	entities = entities[:page.Limit]
+	last := entities[len(entities)-1]
+
+	next := (&cursor{
+		Sort:  page.Sort,
+		Desc:  page.Desc,
+		Value: sortValue(page.Sort, last),
+		Id:    last.Id,
+	}).encode()
+
+	return &Page{
+		Entities:   entities,
+		NextCursor: &next,
+	}
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"Backend/internal/models"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewPageWithoutNextPage(t *testing.T) {
+	entities := []*models.Entity{{Id: "a"}, {Id: "b"}}
+
+	page := newPage(entities, PageRequest{Limit: 2, Sort: SortByName})
+	if len(page.Entities) != 2 {
+		t.Errorf("expected 2 entities, got %v", len(page.Entities))
+	}
+	if page.NextCursor != nil {
+		t.Errorf("expected no next cursor, got %v", *page.NextCursor)
+	}
+}
+
+func TestNewPageCursorRoundTrip(t *testing.T) {
+	created := time.Date(2025, 1, 2, 3, 4, 5, 123456000, time.UTC)
+	entities := []*models.Entity{
+		{Id: "a", CreatedAt: created.Add(-time.Hour)},
+		{Id: "b", CreatedAt: created},
+		{Id: "c", CreatedAt: created.Add(time.Hour)},
+	}
+
+	req := PageRequest{Limit: 2, Sort: SortByCreatedAt, Desc: true}
+	page := newPage(entities, req)
+	if len(page.Entities) != 2 {
+		t.Fatalf("expected the extra entity to be trimmed, got %v entities", len(page.Entities))
+	}
+	if page.NextCursor == nil {
+		t.Fatalf("expected a next cursor")
+	}
+
+	c, err := decodeCursor(*page.NextCursor)
+	if err != nil {
+		t.Fatalf("unable to decode cursor: %v", err)
+	}
+	if c.Id != "b" || c.Sort != SortByCreatedAt || !c.Desc {
+		t.Errorf("cursor does not point after the last entity: %+v", c)
+	}
+
+	value, err := columnValue(c.Sort, c.Value)
+	if err != nil {
+		t.Fatalf("unable to read cursor value: %v", err)
+	}
+	if !value.(time.Time).Equal(created) {
+		t.Errorf("expected %v, got %v", created, value)
+	}
+}
+
+func TestPaginateRejectsForeignCursor(t *testing.T) {
+	c := (&cursor{Sort: SortByName, Value: "box", Id: "a"}).encode()
+
+	if _, err := paginate(nil, PageRequest{Limit: 10, Sort: SortByUpdatedAt, Cursor: c}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor for a cursor of another sort, got %v", err)
+	}
+	if _, err := paginate(nil, PageRequest{Limit: 10, Sort: SortByName, Cursor: "not-a-cursor"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor for garbage, got %v", err)
+	}
+}
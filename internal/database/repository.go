@@ -0,0 +1,95 @@
+package database
+
+import (
+	"Backend/internal/models"
+	"context"
+	"time"
+)
+
+// Repository
+// is the storage the server runs on. Methods that act on entities and their tags, attributes,
+// stock, history, loans, grants and API keys are scoped to the workspace of the context,
+// see WithWorkspace, and fail with ErrNoWorkspace without one.
+type Repository interface {
+	Connect(ctx context.Context) error
+	Disconnect(ctx context.Context) error
+	Migrate(ctx context.Context) error
+	RollbackMigration(ctx context.Context, force bool) (*Migration, error)
+	QueryMigrations(ctx context.Context) ([]*Migration, error)
+
+	// Entities
+	CreateEntity(ctx context.Context, e *models.Entity) error
+	QueryTopLevel(ctx context.Context, page PageRequest) (*Page, error)
+	QueryById(ctx context.Context, id string) (*models.Entity, error)
+	QueryByCode(ctx context.Context, code string) (*models.Entity, error)
+	QueryMultipleById(ctx context.Context, page PageRequest, ids ...string) (*Page, error)
+	QueryFiltered(ctx context.Context, page PageRequest, filter EntityFilter) (*Page, error)
+	QueryAncestors(ctx context.Context, id string) ([]*models.Entity, error)
+	QuerySubtree(ctx context.Context, id string, maxDepth int, maxNodes int) (*models.Entity, bool, error)
+	SearchEntities(ctx context.Context, query string, limit int) ([]*models.SearchHit, error)
+	UpdateEntity(ctx context.Context, id string, opts ...models.NewEntityOption) (*models.Entity, error)
+	DeleteEntity(ctx context.Context, id string, cascade bool) error
+	MoveEntity(ctx context.Context, id string, parentId *string) (*models.Entity, error)
+	QueryDeleted(ctx context.Context) ([]*models.Entity, error)
+	RestoreEntity(ctx context.Context, id string, cascade bool) (*models.Entity, error)
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) ([]*models.Entity, error)
+	QueryImageUrls(ctx context.Context) ([]string, error)
+
+	// Tags
+	CreateTag(ctx context.Context, name string) (*models.Tag, error)
+	RenameTag(ctx context.Context, id string, name string) (*models.Tag, error)
+	QueryTags(ctx context.Context) ([]*models.Tag, error)
+	AttachTags(ctx context.Context, entityId string, names ...string) (*models.Entity, error)
+	DetachTag(ctx context.Context, entityId string, tagId string) (*models.Entity, error)
+
+	// Attributes
+	QueryAttributeSchema(ctx context.Context) ([]*models.AttributeDefinition, error)
+	UpsertAttributeDefinition(ctx context.Context, def *models.AttributeDefinition) error
+	DeleteAttributeDefinition(ctx context.Context, key string) error
+
+	// Stock
+	AdjustQuantity(ctx context.Context, id string, delta int64, reason string) (*models.Entity, error)
+	QueryLowStock(ctx context.Context) ([]*models.Entity, error)
+
+	// History
+	QueryHistory(ctx context.Context, id string, limit int, before time.Time) ([]*models.EntityEvent, error)
+	QueryLocationAt(ctx context.Context, id string, at time.Time) (*models.Location, error)
+
+	// Loans
+	CheckOut(ctx context.Context, id string, borrower string, dueAt *time.Time, note string) (*models.Loan, error)
+	CheckIn(ctx context.Context, id string) (*models.Loan, error)
+	QueryActiveLoans(ctx context.Context) ([]*models.Loan, error)
+	QueryOverdueLoans(ctx context.Context, now time.Time) ([]*models.Loan, error)
+
+	// Users
+	CreateUser(ctx context.Context, user *models.User) error
+	EnsureAdmin(ctx context.Context, user *models.User) (bool, error)
+	Login(ctx context.Context, username string, password string, ttl time.Duration) (string, *models.AuthToken, error)
+	QueryUserByToken(ctx context.Context, token string) (*models.User, error)
+	Logout(ctx context.Context, token string) error
+
+	// Workspaces
+	CreateWorkspace(ctx context.Context, name string, userId string) (*models.Workspace, error)
+	QueryWorkspacesOfUser(ctx context.Context, userId string) ([]*models.Workspace, error)
+	QueryWorkspaceIds(ctx context.Context) ([]string, error)
+	QueryMembership(ctx context.Context, workspaceId string, userId string) (*models.WorkspaceMember, error)
+	QueryWorkspaceMembers(ctx context.Context, workspaceId string) ([]*models.WorkspaceMember, error)
+	AddWorkspaceMember(ctx context.Context, workspaceId string, username string, role models.Role) (*models.WorkspaceMember, error)
+	UpdateMemberRole(ctx context.Context, workspaceId string, userId string, role models.Role) (*models.WorkspaceMember, error)
+	RemoveWorkspaceMember(ctx context.Context, workspaceId string, userId string) error
+	QueryEntityWorkspace(ctx context.Context, entityId string) (string, error)
+
+	// Roles
+	EffectiveRole(ctx context.Context, userId string, entityId string) (models.Role, error)
+	QueryGrants(ctx context.Context, entityId string) ([]*models.SubtreeGrant, error)
+	GrantRole(ctx context.Context, entityId string, userId string, role models.Role) (*models.SubtreeGrant, error)
+	RevokeGrant(ctx context.Context, entityId string, userId string) error
+
+	// API keys
+	CreateApiKey(ctx context.Context, name string, scope models.ApiKeyScope, createdBy string, expiresAt *time.Time) (*models.ApiKey, string, error)
+	QueryApiKeys(ctx context.Context) ([]*models.ApiKey, error)
+	RevokeApiKey(ctx context.Context, id string) error
+	QueryApiKeyByToken(ctx context.Context, token string) (*models.ApiKey, error)
+}
+
+var _ Repository = (*GormAdapter)(nil)
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type workspaceContextKey struct{}
+
+type tagCreationContextKey struct{}
+
+// WithWorkspace
+// returns a context whose queries only see the entities, tags and schema of the workspace.
+func WithWorkspace(ctx context.Context, workspaceId string) context.Context {
+	return context.WithValue(ctx, workspaceContextKey{}, workspaceId)
+}
+
+func WorkspaceFromContext(ctx context.Context) (string, bool) {
+	workspaceId, ok := ctx.Value(workspaceContextKey{}).(string)
+	return workspaceId, ok && workspaceId != ""
+}
+
+// WithoutTagCreation
+// returns a context in which tags given by name are only looked up. Naming a tag that does not
+// exist fails with ErrTagCreationDenied instead of creating it, tags belong to the whole workspace.
+func WithoutTagCreation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tagCreationContextKey{}, false)
+}
+
+func tagCreationAllowed(ctx context.Context) bool {
+	allowed, ok := ctx.Value(tagCreationContextKey{}).(bool)
+	return allowed || !ok
+}
+
+// requireWorkspace
+// fails with ErrNoWorkspace instead of letting an unscoped query through.
+func requireWorkspace(ctx context.Context) (string, error) {
+	workspaceId, ok := WorkspaceFromContext(ctx)
+	if !ok {
+		return "", ErrNoWorkspace
+	}
+	return workspaceId, nil
+}
+
+// inWorkspace
+// restricts a query to the rows of the workspace, on whichever table the query runs.
+func inWorkspace(workspaceId string) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where(clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: "workspace_id"},
+			Value:  workspaceId,
+		})
+	}
+}
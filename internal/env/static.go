@@ -1,10 +1,25 @@
 package env
 
-import "github.com/caarlos0/env/v11"
+import (
+	"errors"
+	"github.com/caarlos0/env/v11"
+	"net/url"
+	"strings"
+	"time"
+)
 
 type StaticEnvStruct struct {
-	ServerPort int `env:"SERVER_PORT"`
+	ServerPort     int    `env:"SERVER_PORT"`
+	PublicBaseUrl  string `env:"PUBLIC_BASE_URL"`                             // e.g. https://tt.example.com, printed on labels so it has to be reachable from phones
+	LabelTargetUrl string `env:"LABEL_TARGET_URL" envDefault:"/?code={code}"` // Page a scanned label opens, {code} is replaced, relative to PUBLIC_BASE_URL unless absolute
+
+	ServerReadTimeout  time.Duration `env:"SERVER_READ_TIMEOUT" envDefault:"60s"` // Covers the upload of request bodies
+	ServerWriteTimeout time.Duration `env:"SERVER_WRITE_TIMEOUT" envDefault:"60s"`
+	ServerIdleTimeout  time.Duration `env:"SERVER_IDLE_TIMEOUT" envDefault:"120s"`
+	ShutdownTimeout    time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"20s"` // Has to end before Docker kills the container, see stop_grace_period
 
+	DbDriver      string `env:"DB_DRIVER" envDefault:"postgres"`          // postgres or sqlite
+	DbPath        string `env:"DB_PATH" envDefault:"./data/tag-track.db"` // Database file when DB_DRIVER is sqlite
 	DbHost        string `env:"DB_HOST"`
 	DbPort        int    `env:"DB_PORT"`
 	DbUser        string `env:"DB_USER"`
@@ -15,6 +30,17 @@ type StaticEnvStruct struct {
 	MinioUser     string `env:"MINIO_USER"`
 	MinioPassword string `env:"MINIO_PASSWORD"`
 	MinioBucket   string `env:"MINIO_BUCKET"`
+
+	ObjectStore     string `env:"OBJECT_STORE" envDefault:"minio"`               // minio, local or memory
+	ObjectStorePath string `env:"OBJECT_STORE_PATH" envDefault:"./data/objects"` // Directory of the local object store
+
+	AdminUsername string        `env:"ADMIN_USERNAME" envDefault:"admin"` // Created on start when there are no users yet
+	AdminPassword string        `env:"ADMIN_PASSWORD"`
+	TokenTtl      time.Duration `env:"TOKEN_TTL" envDefault:"720h"`
+	ImagePublic   bool          `env:"IMAGE_PUBLIC" envDefault:"false"` // Serve /image/v1/ without authentication
+
+	TrashRetention     time.Duration `env:"TRASH_RETENTION" envDefault:"720h"`
+	TrashPurgeInterval time.Duration `env:"TRASH_PURGE_INTERVAL" envDefault:"1h"`
 }
 
 var (
@@ -35,3 +61,30 @@ func GetStaticEnv() *StaticEnvStruct {
 
 	return staticEnv
 }
+
+// CheckPublicBaseUrl
+// fails unless PUBLIC_BASE_URL is an absolute http or https url. Labels encode it, so it cannot
+// be taken from the Host header of whichever request rendered the label first. Without it the
+// server still starts, only labels are unavailable.
+func (e *StaticEnvStruct) CheckPublicBaseUrl() error {
+	if e.PublicBaseUrl == "" {
+		return errors.New("PUBLIC_BASE_URL has to be set, e.g. to https://tt.example.com")
+	}
+	u, err := url.Parse(e.PublicBaseUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("PUBLIC_BASE_URL has to be an absolute http or https url")
+	}
+	return nil
+}
+
+// CheckLabelTargetUrl
+// fails unless LABEL_TARGET_URL names the page of the code, which it has to contain as {code}.
+func (e *StaticEnvStruct) CheckLabelTargetUrl() error {
+	if !strings.Contains(e.LabelTargetUrl, "{code}") {
+		return errors.New("LABEL_TARGET_URL has to contain {code}, e.g. https://tt.example.com/items/{code}")
+	}
+	if _, err := url.Parse(strings.ReplaceAll(e.LabelTargetUrl, "{code}", "CODE")); err != nil {
+		return errors.New("LABEL_TARGET_URL is not a valid url")
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package images
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/objectstore"
+	"Backend/internal/thumbnail"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+)
+
+// BaseName
+// converts an image url as stored on models.Entity into its thumbnail base name.
+func BaseName(imageUrl string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(imageUrl, "/image/v1/"), ".jpeg")
+}
+
+// isThumbnail
+// reports whether the object name is one size of a thumbnail, as opposed to e.g. a cached label.
+func isThumbnail(name string) bool {
+	base, ok := strings.CutSuffix(name, ".jpeg")
+	if !ok {
+		return false
+	}
+	i := strings.LastIndex(base, "_")
+	if i < 0 {
+		return false
+	}
+	_, err := thumbnail.SizeFromAbvr(base[i+1:])
+	return err == nil
+}
+
+////////////////////////////////////////////////
+// Maintenance
+////////////////////////////////////////////////
+
+type GcReport struct {
+	Kept    int      // Thumbnails an entity refers to
+	Young   int      // Unreferenced thumbnails left alone because they are younger than the minimum age
+	Deleted []string // Unreferenced thumbnails, deleted unless the run is dry
+}
+
+// CollectGarbage
+// deletes thumbnails that no entity refers to anymore, entities in the trash included.
+// Uploads happen before their entity is stored, so thumbnails younger than minAge are kept.
+// A dry run only reports what would be deleted.
+func CollectGarbage(
+	ctx context.Context,
+	db database.Repository,
+	objStore objectstore.ObjectStore,
+	minAge time.Duration,
+	dryRun bool,
+) (*GcReport, error) {
+
+	urls, err := db.QueryImageUrls(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := make(map[string]bool, len(urls)*5)
+	for _, url := range urls {
+		for _, name := range thumbnail.ImageNamesFromBaseName(BaseName(url)) {
+			referenced[name] = true
+		}
+	}
+
+	objects, err := objStore.ListObjects(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	report := &GcReport{Deleted: make([]string, 0)}
+	cutoff := time.Now().Add(-minAge)
+	for _, obj := range objects {
+		switch {
+		case !isThumbnail(obj.Name):
+			continue
+		case referenced[obj.Name]:
+			report.Kept++
+		case obj.LastModified.After(cutoff):
+			report.Young++
+		default:
+			if !dryRun {
+				if err := objStore.DeleteImage(ctx, obj.Name); err != nil {
+					return report, fmt.Errorf("unable to delete %s: %w", obj.Name, err)
+				}
+			}
+			report.Deleted = append(report.Deleted, obj.Name)
+		}
+	}
+
+	return report, nil
+}
+
+// RegenerateThumbnails
+// renders every size of every image again from its largest stored size, e.g. after the sizes
+// or the quality of thumbnails changed. The names stay the same, so entities need no update.
+// Images whose largest size is missing are logged and skipped, their base names are returned.
+func RegenerateThumbnails(
+	ctx context.Context,
+	db database.Repository,
+	objStore objectstore.ObjectStore,
+) (int, []string, error) {
+
+	urls, err := db.QueryImageUrls(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	regenerated := 0
+	skipped := make([]string, 0)
+	for _, url := range urls {
+		if err := ctx.Err(); err != nil {
+			return regenerated, skipped, err
+		}
+
+		baseName := BaseName(url)
+		if err := regenerate(ctx, objStore, baseName); err != nil {
+			if errors.Is(err, objectstore.ErrObjectNotFound) {
+				log.Printf("[Warning] Skipping %s, its largest size is missing", baseName)
+				skipped = append(skipped, baseName)
+				continue
+			}
+			return regenerated, skipped, fmt.Errorf("unable to regenerate %s: %w", baseName, err)
+		}
+		regenerated++
+	}
+
+	return regenerated, skipped, nil
+}
+
+func regenerate(ctx context.Context, objStore objectstore.ObjectStore, baseName string) error {
+	abvr, err := thumbnail.ExtraLargeSize.Abvr()
+	if err != nil {
+		return err
+	}
+
+	r, err := objStore.RetrieveImage(ctx, fmt.Sprintf("%s_%s.jpeg", baseName, abvr))
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	_ = r.Close()
+	if err != nil {
+		return err
+	}
+
+	t, err := thumbnail.NewThumbnailsFromBytes(data, "", thumbnail.WithThumbnailBaseName(baseName))
+	if err != nil {
+		return err
+	}
+	return objStore.UploadThumbnail(ctx, t)
+}
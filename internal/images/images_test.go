@@ -0,0 +1,94 @@
+package images
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/models"
+	"Backend/internal/objectstore"
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCollectGarbageAndRegenerate(t *testing.T) {
+	db, err := database.CreateGormSqliteAdapter(filepath.Join(t.TempDir(), "tt.db"))
+	if err != nil {
+		t.Fatalf("unable to create adapter: %v", err)
+	}
+	ctx := context.Background()
+	if err := db.Migrate(ctx); err != nil {
+		t.Fatalf("unable to migrate: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Disconnect(ctx) })
+
+	user, _ := models.NewUser("alice", "correct horse")
+	if err := db.CreateUser(ctx, user); err != nil {
+		t.Fatalf("unable to store user: %v", err)
+	}
+	workspace, err := db.CreateWorkspace(ctx, "Home", user.Id)
+	if err != nil {
+		t.Fatalf("unable to create workspace: %v", err)
+	}
+	wsCtx := database.WithWorkspace(ctx, workspace.Id)
+
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, 64, 32)), nil); err != nil {
+		t.Fatalf("unable to encode image: %v", err)
+	}
+
+	store := objectstore.NewMemoryAdapter()
+	kept := workspace.Id + "/drill_a"
+	trashed := workspace.Id + "/saw_b"
+	orphan := workspace.Id + "/gone_c"
+	for _, name := range []string{kept + "_xl.jpeg", kept + "_m.jpeg", trashed + "_xl.jpeg", orphan + "_xl.jpeg", "labels/drill_0123.png"} {
+		if err := store.UploadImage(ctx, name, img.Bytes()); err != nil {
+			t.Fatalf("unable to upload %s: %v", name, err)
+		}
+	}
+
+	for _, e := range []*models.Entity{
+		models.NewEntity(models.EntityWithId("drill"), models.EntityWithImages([]string{"/image/v1/" + kept + ".jpeg"})),
+		models.NewEntity(models.EntityWithId("saw"), models.EntityWithImages([]string{"/image/v1/" + trashed + ".jpeg"})),
+	} {
+		if err := db.CreateEntity(wsCtx, e); err != nil {
+			t.Fatalf("unable to create %s: %v", e.Id, err)
+		}
+	}
+	if err := db.DeleteEntity(wsCtx, "saw", false); err != nil {
+		t.Fatalf("unable to trash the saw: %v", err)
+	}
+
+	// Everything was just uploaded and could belong to an entity being created
+	report, err := CollectGarbage(ctx, db, store, time.Hour, false)
+	if err != nil {
+		t.Fatalf("unable to collect garbage: %v", err)
+	}
+	if report.Young != 1 || len(report.Deleted) != 0 {
+		t.Errorf("expected the orphan to be kept as young, got %+v", report)
+	}
+
+	report, err = CollectGarbage(ctx, db, store, 0, false)
+	if err != nil {
+		t.Fatalf("unable to collect garbage: %v", err)
+	}
+	if report.Kept != 3 || len(report.Deleted) != 1 || report.Deleted[0] != orphan+"_xl.jpeg" {
+		t.Errorf("expected only the orphan to be deleted, got %+v", report)
+	}
+	if _, err := store.RetrieveImage(ctx, "labels/drill_0123.png"); err != nil {
+		t.Errorf("expected cached labels to be left alone, got %v", err)
+	}
+
+	regenerated, skipped, err := RegenerateThumbnails(ctx, db, store)
+	if err != nil {
+		t.Fatalf("unable to regenerate: %v", err)
+	}
+	if regenerated != 2 || len(skipped) != 0 {
+		t.Errorf("expected both images to be regenerated, got %d and skipped %v", regenerated, skipped)
+	}
+	if _, err := store.RetrieveImage(ctx, kept+"_xs.jpeg"); err != nil {
+		t.Errorf("expected the missing sizes to be rendered, got %v", err)
+	}
+}
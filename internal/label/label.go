@@ -0,0 +1,246 @@
+package label
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+const (
+	MinSize     = 128
+	MaxSize     = 1024
+	DefaultSize = 300
+)
+
+// glyph dimensions of basicfont.Face7x13
+const (
+	glyphWidth  = 7
+	glyphHeight = 13
+)
+
+const pathSeparator = " > "
+
+var ErrInvalidSize = fmt.Errorf("label size must be between %v and %v", MinSize, MaxSize)
+
+// Label
+// is a QR code pointing at Url with the entity name and the path of its
+// containers printed beneath it. Size is the width of the label in pixels.
+type Label struct {
+	Url  string
+	Name string
+	Path []string // Ancestor names from the top level down
+	Size int
+}
+
+type NewLabelOption func(l *Label)
+
+func NewLabel(url string, opts ...NewLabelOption) *Label {
+	l := &Label{
+		Url:  url,
+		Size: DefaultSize,
+	}
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+func LabelWithName(name string) NewLabelOption {
+	return func(l *Label) {
+		l.Name = name
+	}
+}
+
+func LabelWithPath(path []string) NewLabelOption {
+	return func(l *Label) {
+		l.Path = path
+	}
+}
+
+func LabelWithSize(size int) NewLabelOption {
+	return func(l *Label) {
+		l.Size = size
+	}
+}
+
+func (l *Label) validate() error {
+	if l.Size < MinSize || l.Size > MaxSize {
+		return ErrInvalidSize
+	}
+	if l.Url == "" {
+		return errors.New("label url is empty")
+	}
+	return nil
+}
+
+// CacheKey
+// returns the object name of the rendered label, it changes whenever anything printed on it does.
+func (l *Label) CacheKey(entityId string, ext string) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%v\n%v\n%v\n%v", l.Url, l.Name, strings.Join(l.Path, "\x00"), l.Size)
+	return fmt.Sprintf("%s%s.%s", CachePrefix(entityId), hex.EncodeToString(h.Sum(nil))[:16], ext)
+}
+
+// CachePrefix
+// returns the start of the object names of every label rendered for the entity, see CacheKey.
+func CachePrefix(entityId string) string {
+	return fmt.Sprintf("labels/%s_", entityId)
+}
+
+// lines returns the text printed beneath the code, cut to fit maxChars characters.
+// The path is cut from the front so that the nearest containers stay readable.
+func (l *Label) lines(maxChars int) []string {
+	res := make([]string, 0, 2)
+
+	if name := []rune(l.Name); len(name) > 0 {
+		if len(name) > maxChars {
+			name = append(name[:maxChars-3], []rune("...")...)
+		}
+		res = append(res, string(name))
+	}
+
+	if path := []rune(strings.Join(l.Path, pathSeparator)); len(path) > 0 {
+		if len(path) > maxChars {
+			path = append([]rune("..."), path[len(path)-maxChars+3:]...)
+		}
+		res = append(res, string(path))
+	}
+
+	return res
+}
+
+// textScale is the factor basicfont is scaled up by, so that the text grows with the label
+func (l *Label) textScale() int {
+	return max(1, l.Size/160)
+}
+
+func (l *Label) PNG() ([]byte, error) {
+	if err := l.validate(); err != nil {
+		return nil, err
+	}
+
+	q, err := qrcode.New(l.Url, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+
+	scale := l.textScale()
+	lineHeight := (glyphHeight + 4) * scale
+	lines := l.lines(l.Size / (glyphWidth * scale))
+
+	canvas := image.NewRGBA(image.Rect(0, 0, l.Size, l.Size+len(lines)*lineHeight+4*scale))
+	draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(canvas, image.Rect(0, 0, l.Size, l.Size), q.Image(l.Size), image.Point{}, draw.Src)
+
+	for i, line := range lines {
+		// Render at the native glyph size and scale up, basicfont only comes in 7x13
+		width := len([]rune(line)) * glyphWidth
+		small := image.NewRGBA(image.Rect(0, 0, width, glyphHeight))
+		draw.Draw(small, small.Bounds(), image.White, image.Point{}, draw.Src)
+
+		d := &font.Drawer{
+			Dst:  small,
+			Src:  image.NewUniform(color.Black),
+			Face: basicfont.Face7x13,
+			Dot:  fixed.P(0, basicfont.Face7x13.Ascent),
+		}
+		d.DrawString(line)
+
+		x := (l.Size - width*scale) / 2
+		y := l.Size + i*lineHeight
+		draw.NearestNeighbor.Scale(
+			canvas,
+			image.Rect(x, y, x+width*scale, y+glyphHeight*scale),
+			small,
+			small.Bounds(),
+			draw.Src,
+			nil,
+		)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		return nil, fmt.Errorf("failed to encode PNG: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (l *Label) SVG() ([]byte, error) {
+	if err := l.validate(); err != nil {
+		return nil, err
+	}
+
+	q, err := qrcode.New(l.Url, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+
+	bitmap := q.Bitmap()
+	module := float64(l.Size) / float64(len(bitmap))
+
+	fontSize := float64(glyphHeight * l.textScale())
+	lineHeight := fontSize * 1.3
+	lines := l.lines(l.Size / (glyphWidth * l.textScale()))
+	height := float64(l.Size) + float64(len(lines))*lineHeight + fontSize/2
+
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(
+		&sb,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="%.0[2]f" viewBox="0 0 %[1]d %.0[2]f">`,
+		l.Size,
+		height,
+	)
+	_, _ = fmt.Fprintf(&sb, `<rect width="100%%" height="100%%" fill="#fff"/>`)
+
+	// One horizontal run of dark modules per path segment keeps the file small
+	sb.WriteString(`<path fill="#000" d="`)
+	for y, row := range bitmap {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			_, _ = fmt.Fprintf(
+				&sb,
+				"M%.2f %.2fh%.2fv%.2fh-%.2fz",
+				float64(start)*module,
+				float64(y)*module,
+				float64(x-start)*module,
+				module,
+				float64(x-start)*module,
+			)
+		}
+	}
+	sb.WriteString(`"/>`)
+
+	for i, line := range lines {
+		_, _ = fmt.Fprintf(
+			&sb,
+			`<text x="%.2f" y="%.2f" font-family="monospace" font-size="%.0f" text-anchor="middle">%s</text>`,
+			float64(l.Size)/2,
+			float64(l.Size)+float64(i+1)*lineHeight-fontSize*0.3,
+			fontSize,
+			html.EscapeString(line),
+		)
+	}
+
+	sb.WriteString(`</svg>`)
+
+	return []byte(sb.String()), nil
+}
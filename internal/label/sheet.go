@@ -0,0 +1,161 @@
+package label
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/go-pdf/fpdf"
+	"github.com/skip2/go-qrcode"
+)
+
+// SheetLayout
+// describes a sheet of sticker labels, all lengths are in millimetres.
+type SheetLayout struct {
+	PageSize    string // As understood by fpdf, e.g. "A4" or "Letter"
+	Columns     int
+	Rows        int
+	LabelWidth  float64
+	LabelHeight float64
+	MarginTop   float64
+	MarginLeft  float64
+	PitchX      float64 // Distance between the left edges of neighbouring labels
+	PitchY      float64 // Distance between the top edges of neighbouring labels
+}
+
+func (s *SheetLayout) PerPage() int {
+	return s.Columns * s.Rows
+}
+
+var SheetLayouts = map[string]*SheetLayout{
+	// 1" x 2 5/8", 30 per Letter sheet
+	"avery5160": {
+		PageSize:    "Letter",
+		Columns:     3,
+		Rows:        10,
+		LabelWidth:  66.675,
+		LabelHeight: 25.4,
+		MarginTop:   12.7,
+		MarginLeft:  4.7625,
+		PitchX:      69.85,
+		PitchY:      25.4,
+	},
+	// 63.5 x 38.1 mm, 21 per A4 sheet
+	"averyL7160": {
+		PageSize:    "A4",
+		Columns:     3,
+		Rows:        7,
+		LabelWidth:  63.5,
+		LabelHeight: 38.1,
+		MarginTop:   15.15,
+		MarginLeft:  7.25,
+		PitchX:      66.04,
+		PitchY:      38.1,
+	},
+	// 63.5 x 33.9 mm, 24 per A4 sheet
+	"averyL7159": {
+		PageSize:    "A4",
+		Columns:     3,
+		Rows:        8,
+		LabelWidth:  63.5,
+		LabelHeight: 33.9,
+		MarginTop:   12.9,
+		MarginLeft:  7.25,
+		PitchX:      66.04,
+		PitchY:      33.9,
+	},
+	// 99.1 x 38.1 mm, 14 per A4 sheet
+	"averyL7163": {
+		PageSize:    "A4",
+		Columns:     2,
+		Rows:        7,
+		LabelWidth:  99.1,
+		LabelHeight: 38.1,
+		MarginTop:   15.15,
+		MarginLeft:  4.65,
+		PitchX:      101.6,
+		PitchY:      38.1,
+	},
+}
+
+// sheetQrPx is the pixel size QR codes are rasterised at before being placed on the sheet
+const sheetQrPx = 256
+
+// SheetItem is one label on a sheet
+type SheetItem struct {
+	Url  string
+	Name string
+	Code string
+}
+
+// Sheet
+// renders the items as a PDF of label sheets, starting skip positions into the first
+// sheet so that partially used sheets can be printed on again.
+func Sheet(layout *SheetLayout, items []*SheetItem, skip int) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", layout.PageSize, "")
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetTitle("Tag Track labels", true)
+
+	// Core fonts are cp1252, translate so that accented names survive
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	const padding = 2.0
+	qrSide := layout.LabelHeight - 2*padding
+
+	for i, item := range items {
+		pos := (i + skip) % layout.PerPage()
+		if i == 0 || pos == 0 {
+			pdf.AddPage()
+		}
+
+		x := layout.MarginLeft + float64(pos%layout.Columns)*layout.PitchX
+		y := layout.MarginTop + float64(pos/layout.Columns)*layout.PitchY
+
+		qr, err := qrcode.New(item.Url, qrcode.Medium)
+		if err != nil {
+			return nil, err
+		}
+		qr.DisableBorder = true
+
+		img, err := qr.PNG(sheetQrPx)
+		if err != nil {
+			return nil, err
+		}
+
+		imgName := fmt.Sprintf("qr%d", i)
+		opts := fpdf.ImageOptions{ImageType: "PNG"}
+		pdf.RegisterImageOptionsReader(imgName, opts, bytes.NewReader(img))
+		pdf.ImageOptions(imgName, x+padding, y+padding, qrSide, qrSide, false, opts, 0, "")
+
+		textX := x + qrSide + 2*padding
+		textWidth := layout.LabelWidth - qrSide - 3*padding
+
+		// Font sizes are in points, keep them in proportion to the label height
+		nameSize := min(12, layout.LabelHeight*0.45)
+		pdf.SetFont("Helvetica", "B", nameSize)
+		pdf.SetXY(textX, y+padding)
+		pdf.CellFormat(textWidth, layout.LabelHeight*0.3, fitText(pdf, tr(item.Name), textWidth), "", 0, "L", false, 0, "")
+
+		pdf.SetFont("Courier", "", nameSize*0.8)
+		pdf.SetXY(textX, y+layout.LabelHeight-padding-layout.LabelHeight*0.25)
+		pdf.CellFormat(textWidth, layout.LabelHeight*0.25, fitText(pdf, tr(item.Code), textWidth), "", 0, "L", false, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to create PDF: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// fitText cuts the text down until it fits the width in the current font
+func fitText(pdf *fpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
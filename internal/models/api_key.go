@@ -0,0 +1,125 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// ApiKeyPrefix marks API keys, so that they can be told apart from login tokens and found by secret scanners
+const ApiKeyPrefix = "ttk_"
+
+// apiKeyHintLength is how much of the key is kept in clear to recognize it in listings
+const apiKeyHintLength = len(ApiKeyPrefix) + 6
+
+// ApiKeyScope limits what an API key may do in its workspace
+type ApiKeyScope string
+
+const (
+	ApiKeyScopeRead  ApiKeyScope = "read"
+	ApiKeyScopeWrite ApiKeyScope = "write"
+)
+
+var ErrInvalidApiKeyScope = errors.New("scope must be read or write")
+
+// ApiKey
+// lets scanners and scripts use a single workspace without personal credentials.
+// Like AuthToken only the SHA-256 of the key is stored, the key is handed out once by NewApiKey.
+type ApiKey struct {
+	Id          string      `json:"id" gorm:"primaryKey"`
+	WorkspaceId string      `json:"workspace_id" gorm:"index;not null"`
+	Workspace   *Workspace  `json:"-" gorm:"foreignKey:WorkspaceId;constraint:OnDelete:CASCADE"`
+	Name        string      `json:"name" gorm:"not null"`
+	Hint        string      `json:"hint" gorm:"not null"`
+	KeyHash     string      `json:"-" gorm:"uniqueIndex;not null"`
+	Scope       ApiKeyScope `json:"scope" gorm:"not null"`
+	CreatedBy   string      `json:"created_by"`
+	ExpiresAt   *time.Time  `json:"expires_at"`
+	LastUsedAt  *time.Time  `json:"last_used_at"`
+	CreatedAt   time.Time   `json:"created_at" gorm:"autoCreateTime"`
+}
+
+////////////////////////////////////////////////
+// DB Hook methods
+////////////////////////////////////////////////
+
+func (k *ApiKey) BeforeCreate(tx *gorm.DB) (err error) {
+	if k.Id == "" {
+		k.Id = cuid.New()
+	}
+	return nil
+}
+
+////////////////////////////////////////////////
+// Constructors
+////////////////////////////////////////////////
+
+// NewApiKey
+// returns a fresh random key and the record to store for it, a nil expiresAt never expires.
+func NewApiKey(
+	workspaceId string,
+	name string,
+	scope ApiKeyScope,
+	createdBy string,
+	expiresAt *time.Time,
+) (string, *ApiKey, error) {
+	raw := make([]byte, tokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	key := ApiKeyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	return key, &ApiKey{
+		WorkspaceId: workspaceId,
+		Name:        name,
+		Hint:        key[:apiKeyHintLength],
+		KeyHash:     HashToken(key),
+		Scope:       scope,
+		CreatedBy:   createdBy,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////
+
+func ParseApiKeyScope(raw string) (ApiKeyScope, error) {
+	switch scope := ApiKeyScope(strings.ToLower(strings.TrimSpace(raw))); scope {
+	case ApiKeyScopeRead, ApiKeyScopeWrite:
+		return scope, nil
+	default:
+		return "", ErrInvalidApiKeyScope
+	}
+}
+
+// Role
+// maps the scope onto the workspace roles, keys can never act as owners.
+func (s ApiKeyScope) Role() Role {
+	switch s {
+	case ApiKeyScopeWrite:
+		return RoleEditor
+	case ApiKeyScopeRead:
+		return RoleViewer
+	default:
+		return ""
+	}
+}
+
+// IsApiKey reports whether a bearer token is an API key rather than a login token
+func IsApiKey(token string) bool {
+	return strings.HasPrefix(token, ApiKeyPrefix)
+}
+
+// CappedAt
+// limits the scope to what the role allows, a key never does more than the member who created it.
+func (s ApiKeyScope) CappedAt(role Role) ApiKeyScope {
+	if role.Allows(s.Role()) {
+		return s
+	}
+	return ApiKeyScopeRead
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewApiKeyStoresHashOnly(t *testing.T) {
+	key, record, err := NewApiKey("ws", "scanner", ApiKeyScopeRead, "user", nil)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !IsApiKey(key) {
+		t.Errorf("expected the key to start with %q, got %q", ApiKeyPrefix, key)
+	}
+	if record.KeyHash != HashToken(key) || strings.Contains(record.KeyHash, key) {
+		t.Errorf("expected only the hash of the key to be stored")
+	}
+	if !strings.HasPrefix(key, record.Hint) || len(record.Hint) >= len(key) {
+		t.Errorf("expected the hint to be a short prefix of the key, got %q", record.Hint)
+	}
+}
+
+func TestApiKeyScopeRole(t *testing.T) {
+	scope, err := ParseApiKeyScope(" Write ")
+	if err != nil || scope.Role() != RoleEditor {
+		t.Errorf("expected write to act as editor, got %q (%v)", scope.Role(), err)
+	}
+	if ApiKeyScopeRead.Role() != RoleViewer {
+		t.Errorf("expected read to act as viewer, got %q", ApiKeyScopeRead.Role())
+	}
+	if _, err := ParseApiKeyScope("admin"); !errors.Is(err, ErrInvalidApiKeyScope) {
+		t.Errorf("expected ErrInvalidApiKeyScope, got %v", err)
+	}
+}
+
+func TestApiKeyScopeCappedAt(t *testing.T) {
+	if scope := ApiKeyScopeWrite.CappedAt(RoleViewer); scope != ApiKeyScopeRead {
+		t.Errorf("expected a write key of a viewer to read only, got %q", scope)
+	}
+	if scope := ApiKeyScopeWrite.CappedAt(RoleOwner); scope != ApiKeyScopeWrite {
+		t.Errorf("expected a write key of an owner to keep writing, got %q", scope)
+	}
+	if scope := ApiKeyScopeRead.CappedAt(RoleEditor); scope != ApiKeyScopeRead {
+		t.Errorf("expected a read key to stay read only, got %q", scope)
+	}
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+)
+
+type AttributeType string
+
+const (
+	AttributeTypeString AttributeType = "string"
+	AttributeTypeNumber AttributeType = "number"
+	AttributeTypeDate   AttributeType = "date"
+	AttributeTypeBool   AttributeType = "bool"
+	AttributeTypeEnum   AttributeType = "enum"
+)
+
+// AttributeDateLayout is the form dates are stored in, it sorts the same as text and as a date
+const AttributeDateLayout = "2006-01-02"
+
+var ErrInvalidAttribute = errors.New("invalid attribute")
+
+var attributeKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// Attributes holds the custom values of an entity, scalars only
+type Attributes map[string]any
+
+// AttributeDefinition
+// declares the type of an attribute key. Keys without a definition take any scalar value.
+type AttributeDefinition struct {
+	WorkspaceId string        `json:"workspace_id" gorm:"primaryKey"`
+	Key         string        `json:"key" gorm:"primaryKey"`
+	Type        AttributeType `json:"type" gorm:"not null"`
+	Options     []string      `json:"options,omitempty" gorm:"serializer:json"` // Allowed values of an enum
+	CreatedAt   time.Time     `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time     `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func attributeError(key string, format string, args ...any) error {
+	return fmt.Errorf("%w %q: %s", ErrInvalidAttribute, key, fmt.Sprintf(format, args...))
+}
+
+func ValidateAttributeKey(key string) error {
+	if !attributeKeyPattern.MatchString(key) {
+		return attributeError(key, "keys are 1 to 64 letters, digits, '_', '.' or '-'")
+	}
+	return nil
+}
+
+// Check
+// validates the definition itself.
+func (d *AttributeDefinition) Check() error {
+	if err := ValidateAttributeKey(d.Key); err != nil {
+		return err
+	}
+
+	switch d.Type {
+	case AttributeTypeString, AttributeTypeNumber, AttributeTypeDate, AttributeTypeBool:
+		if len(d.Options) > 0 {
+			return attributeError(d.Key, "options are only allowed on enums")
+		}
+	case AttributeTypeEnum:
+		if len(d.Options) == 0 {
+			return attributeError(d.Key, "enums need at least one option")
+		}
+	default:
+		return attributeError(d.Key, "unknown type %q", d.Type)
+	}
+
+	return nil
+}
+
+// Normalize
+// validates the value against the definition and returns it in its stored form.
+func (d *AttributeDefinition) Normalize(value any) (any, error) {
+	switch d.Type {
+	case AttributeTypeString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case AttributeTypeNumber:
+		if f, ok := value.(float64); ok {
+			return f, nil
+		}
+	case AttributeTypeBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case AttributeTypeDate:
+		if s, ok := value.(string); ok {
+			for _, layout := range []string{AttributeDateLayout, time.RFC3339} {
+				if t, err := time.Parse(layout, s); err == nil {
+					return t.Format(AttributeDateLayout), nil
+				}
+			}
+			return nil, attributeError(d.Key, "dates are written as YYYY-MM-DD")
+		}
+	case AttributeTypeEnum:
+		if s, ok := value.(string); ok {
+			if slices.Contains(d.Options, s) {
+				return s, nil
+			}
+			return nil, attributeError(d.Key, "must be one of %v", d.Options)
+		}
+	}
+
+	return nil, attributeError(d.Key, "expected a value of type %s", d.Type)
+}
+
+// NormalizeAttributes
+// validates every value against its definition, values of undefined keys only have to be scalars.
+func NormalizeAttributes(defs map[string]*AttributeDefinition, attrs Attributes) (Attributes, error) {
+	res := make(Attributes, len(attrs))
+
+	for key, value := range attrs {
+		if err := ValidateAttributeKey(key); err != nil {
+			return nil, err
+		}
+
+		if def, ok := defs[key]; ok {
+			normalized, err := def.Normalize(value)
+			if err != nil {
+				return nil, err
+			}
+			res[key] = normalized
+			continue
+		}
+
+		switch value.(type) {
+		case string, float64, bool:
+			res[key] = value
+		default:
+			return nil, attributeError(key, "only strings, numbers and booleans are allowed")
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeAttributes(t *testing.T) {
+	defs := map[string]*AttributeDefinition{
+		"price":    {Key: "price", Type: AttributeTypeNumber},
+		"purchase": {Key: "purchase", Type: AttributeTypeDate},
+		"color":    {Key: "color", Type: AttributeTypeEnum, Options: []string{"red", "blue"}},
+	}
+
+	attrs, err := NormalizeAttributes(defs, Attributes{
+		"price":    12.5,
+		"purchase": "2024-03-01T10:00:00Z",
+		"color":    "red",
+		"serial":   "SN-123",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attrs["purchase"] != "2024-03-01" {
+		t.Errorf("expected date to be normalized, got %v", attrs["purchase"])
+	}
+	if attrs["serial"] != "SN-123" {
+		t.Errorf("expected undefined key to be kept, got %v", attrs["serial"])
+	}
+}
+
+func TestNormalizeAttributesRejects(t *testing.T) {
+	defs := map[string]*AttributeDefinition{
+		"price": {Key: "price", Type: AttributeTypeNumber},
+		"color": {Key: "color", Type: AttributeTypeEnum, Options: []string{"red", "blue"}},
+	}
+
+	cases := []Attributes{
+		{"price": "cheap"},
+		{"color": "green"},
+		{"nested": map[string]any{"a": 1.0}},
+		{"bad key": "x"},
+	}
+	for _, attrs := range cases {
+		if _, err := NormalizeAttributes(defs, attrs); !errors.Is(err, ErrInvalidAttribute) {
+			t.Errorf("NormalizeAttributes(%v) = %v, want ErrInvalidAttribute", attrs, err)
+		}
+	}
+}
+
+func TestAttributeDefinitionCheck(t *testing.T) {
+	if err := (&AttributeDefinition{Key: "color", Type: AttributeTypeEnum}).Check(); err == nil {
+		t.Errorf("expected an enum without options to be rejected")
+	}
+	if err := (&AttributeDefinition{Key: "size", Type: "length"}).Check(); err == nil {
+		t.Errorf("expected an unknown type to be rejected")
+	}
+	if err := (&AttributeDefinition{Key: "warranty", Type: AttributeTypeDate}).Check(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
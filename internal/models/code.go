@@ -0,0 +1,59 @@
+package models
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+)
+
+// Codes use Crockford's base32 alphabet, which leaves out I, L, O and U so that
+// a code read off a label cannot be mistyped into another valid code.
+const codeAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+const (
+	codeLength    = 8
+	codeMinLength = 4
+	codeMaxLength = 16
+)
+
+var ErrInvalidCode = errors.New("code must be 4 to 16 letters or digits")
+
+// NewCode
+// returns a random code of codeLength characters.
+func NewCode() string {
+	b := make([]byte, codeLength)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	for i := range b {
+		b[i] = codeAlphabet[int(b[i])%len(codeAlphabet)]
+	}
+	return string(b)
+}
+
+// NormalizeCode
+// converts a typed or scanned code to its stored form. Case, dashes and spaces are
+// ignored and the look-alike letters I, L and O are read as 1, 1 and 0.
+func NormalizeCode(code string) (string, error) {
+	var sb strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		switch r {
+		case '-', ' ':
+			continue
+		case 'I', 'L':
+			r = '1'
+		case 'O':
+			r = '0'
+		}
+		if !strings.ContainsRune(codeAlphabet, r) {
+			return "", ErrInvalidCode
+		}
+		sb.WriteRune(r)
+	}
+
+	if sb.Len() < codeMinLength || sb.Len() > codeMaxLength {
+		return "", ErrInvalidCode
+	}
+
+	return sb.String(), nil
+}
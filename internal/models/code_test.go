@@ -0,0 +1,46 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewCodeIsNormalized(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		code := NewCode()
+		normalized, err := NormalizeCode(code)
+		if err != nil {
+			t.Fatalf("generated code %v is invalid: %v", code, err)
+		}
+		if normalized != code {
+			t.Fatalf("generated code %v normalizes to %v", code, normalized)
+		}
+	}
+}
+
+func TestNormalizeCode(t *testing.T) {
+	cases := map[string]string{
+		"k7qm-3xpa":  "K7QM3XPA",
+		"BOX 1L":     "B0X11",
+		"  abcd  ":   "ABCD",
+		"0123456789": "0123456789",
+	}
+	for in, want := range cases {
+		got, err := NormalizeCode(in)
+		if err != nil {
+			t.Errorf("NormalizeCode(%q) failed: %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("NormalizeCode(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNormalizeCodeRejects(t *testing.T) {
+	for _, in := range []string{"", "abc", "ABCDEFGHJKMNPQRST", "SHELF#2", "BÜRO"} {
+		if _, err := NormalizeCode(in); !errors.Is(err, ErrInvalidCode) {
+			t.Errorf("NormalizeCode(%q) = %v, want ErrInvalidCode", in, err)
+		}
+	}
+}
@@ -1,21 +1,30 @@
 package models
 
 import (
+	"errors"
 	"fmt"
-	"github.com/lib/pq"
 	"github.com/lucsky/cuid"
 	"gorm.io/gorm"
 	"time"
 )
 
+var ErrEntityHasChildren = errors.New("entity has child entities")
+
 type Entity struct {
 	Id          string         `json:"id" gorm:"primaryKey"`
-	ParentId    *string        `json:"parent_id" gorm:"index"`       // Allow null for top-level entities
-	Parent      *Entity        `json:"-" gorm:"foreignKey:ParentId"` // Self-referencing relationship
+	WorkspaceId string         `json:"workspace_id" gorm:"index;uniqueIndex:idx_entities_workspace_code,priority:1"`
+	ParentId    *string        `json:"parent_id" gorm:"index"`                                         // Allow null for top-level entities
+	Code        *string        `json:"code" gorm:"uniqueIndex:idx_entities_workspace_code,priority:2"` // Short code printed on the physical label, unique per workspace
+	Parent      *Entity        `json:"-" gorm:"foreignKey:ParentId"`                                   // Self-referencing relationship
 	Children    []*Entity      `json:"children" gorm:"foreignKey:ParentId"`
 	Name        string         `json:"name"`
 	Description string         `json:"description"`
-	Images      pq.StringArray `json:"images" gorm:"type:text[]"`
+	Images      StringList     `json:"images"`
+	Tags        []*Tag         `json:"tags" gorm:"many2many:entity_tags"`
+	Attributes  Attributes     `json:"attributes" gorm:"type:jsonb;serializer:json;not null;default:'{}'"`
+	Quantity    int64          `json:"quantity" gorm:"not null;default:1;check:chk_entities_quantity,quantity >= 0"`
+	Unit        string         `json:"unit"`                      // e.g. "pcs" or "m", empty for single items
+	MinQuantity *int64         `json:"min_quantity" gorm:"index"` // Low-stock threshold, nil disables the report
 	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
@@ -29,6 +38,10 @@ func (e *Entity) BeforeCreate(tx *gorm.DB) (err error) {
 	if e.Id == "" {
 		e.Id = cuid.New()
 	}
+	if e.Code == nil {
+		code := NewCode()
+		e.Code = &code
+	}
 	return nil
 }
 
@@ -42,7 +55,7 @@ func (e *Entity) BeforeDelete(tx *gorm.DB) (err error) {
 
 	// If there are children, prevent deletion
 	if count > 0 {
-		return fmt.Errorf("cannot delete entity with ID %s because it has child entities", e.Id)
+		return fmt.Errorf("cannot delete entity with ID %s: %w", e.Id, ErrEntityHasChildren)
 	}
 	return nil
 }
@@ -56,7 +69,7 @@ type NewEntityOption func(entity *Entity)
 func NewEntity(
 	opts ...NewEntityOption,
 ) *Entity {
-	entity := &Entity{}
+	entity := &Entity{Quantity: 1}
 	for _, o := range opts {
 		o(entity)
 	}
@@ -93,6 +106,78 @@ func EntityWithParentId(id string) NewEntityOption {
 
 func EntityWithImages(imageUrls []string) NewEntityOption {
 	return func(e *Entity) {
-		e.Images = pq.StringArray(imageUrls)
+		e.Images = StringList(imageUrls)
+	}
+}
+
+func EntityWithAddedImages(imageUrls []string) NewEntityOption {
+	return func(e *Entity) {
+		e.Images = append(e.Images, imageUrls...)
+	}
+}
+
+// EntityWithTags
+// sets the tags by name, they are resolved to existing tags or created when the entity is stored.
+func EntityWithTags(names []string) NewEntityOption {
+	return func(e *Entity) {
+		e.Tags = make([]*Tag, 0, len(names))
+		for _, n := range NormalizeTagNames(names) {
+			e.Tags = append(e.Tags, &Tag{Name: n})
+		}
+	}
+}
+
+// EntityWithCode
+// expects a normalized code, see NormalizeCode. An empty code is generated on create.
+func EntityWithCode(code string) NewEntityOption {
+	return func(e *Entity) {
+		if code == "" {
+			e.Code = nil
+			return
+		}
+		e.Code = &code
+	}
+}
+
+func EntityWithAttributes(attrs Attributes) NewEntityOption {
+	return func(e *Entity) {
+		e.Attributes = attrs
+	}
+}
+
+// EntityWithMergedAttributes
+// sets the given attributes on top of the existing ones, a nil value removes the key.
+func EntityWithMergedAttributes(attrs Attributes) NewEntityOption {
+	return func(e *Entity) {
+		if e.Attributes == nil {
+			e.Attributes = make(Attributes, len(attrs))
+		}
+		for key, value := range attrs {
+			if value == nil {
+				delete(e.Attributes, key)
+				continue
+			}
+			e.Attributes[key] = value
+		}
+	}
+}
+
+func EntityWithQuantity(quantity int64) NewEntityOption {
+	return func(e *Entity) {
+		e.Quantity = quantity
+	}
+}
+
+func EntityWithUnit(unit string) NewEntityOption {
+	return func(e *Entity) {
+		e.Unit = unit
+	}
+}
+
+// EntityWithMinQuantity
+// sets the low-stock threshold, nil removes it.
+func EntityWithMinQuantity(minQuantity *int64) NewEntityOption {
+	return func(e *Entity) {
+		e.MinQuantity = minQuantity
 	}
 }
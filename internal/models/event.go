@@ -0,0 +1,105 @@
+package models
+
+import (
+	"errors"
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+type EntityAction string
+
+const (
+	EntityActionCreate  EntityAction = "create"
+	EntityActionUpdate  EntityAction = "update"
+	EntityActionMove    EntityAction = "move"
+	EntityActionDelete  EntityAction = "delete"
+	EntityActionRestore EntityAction = "restore"
+)
+
+var ErrEventImmutable = errors.New("entity history cannot be changed")
+
+// EntityEvent
+// is one row of the append-only history of an entity. Before is nil for creates
+// and restores, After is nil for deletes.
+type EntityEvent struct {
+	Id          string          `json:"id" gorm:"primaryKey"`
+	WorkspaceId string          `json:"workspace_id" gorm:"index"`
+	EntityId    string          `json:"entity_id" gorm:"index:idx_entity_events_entity_time,priority:1;not null"`
+	Action      EntityAction    `json:"action" gorm:"not null"`
+	Actor       string          `json:"actor" gorm:"not null"`
+	ParentId    *string         `json:"parent_id"` // Location after the event, nil at the top level or once deleted
+	Before      *EntitySnapshot `json:"before" gorm:"type:jsonb;serializer:json"`
+	After       *EntitySnapshot `json:"after" gorm:"type:jsonb;serializer:json"`
+	CreatedAt   time.Time       `json:"created_at" gorm:"index:idx_entity_events_entity_time,priority:2;autoCreateTime"`
+}
+
+// EntitySnapshot holds the own fields of an entity at one point in time
+type EntitySnapshot struct {
+	Id          string     `json:"id"`
+	ParentId    *string    `json:"parent_id"`
+	Code        *string    `json:"code"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Images      []string   `json:"images"`
+	Tags        []string   `json:"tags"`
+	Attributes  Attributes `json:"attributes"`
+	Quantity    int64      `json:"quantity"`
+	Unit        string     `json:"unit"`
+	MinQuantity *int64     `json:"min_quantity"`
+}
+
+// Location answers where an entity was at a given time
+type Location struct {
+	EntityId string            `json:"entity_id"`
+	At       time.Time         `json:"at"`
+	Deleted  bool              `json:"deleted"`
+	ParentId *string           `json:"parent_id"`
+	Path     []*EntitySnapshot `json:"path"` // Ancestors as they were at that time, from the top level down
+}
+
+////////////////////////////////////////////////
+// DB Hook methods
+////////////////////////////////////////////////
+
+func (ev *EntityEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	if ev.Id == "" {
+		ev.Id = cuid.New()
+	}
+	return nil
+}
+
+func (ev *EntityEvent) BeforeUpdate(tx *gorm.DB) (err error) {
+	return ErrEventImmutable
+}
+
+func (ev *EntityEvent) BeforeDelete(tx *gorm.DB) (err error) {
+	return ErrEventImmutable
+}
+
+////////////////////////////////////////////////
+// Constructors
+////////////////////////////////////////////////
+
+// NewEntitySnapshot
+// copies the fields of the entity, Tags have to be loaded for them to show up.
+func NewEntitySnapshot(e *Entity) *EntitySnapshot {
+	tags := make([]string, 0, len(e.Tags))
+	for _, t := range e.Tags {
+		tags = append(tags, t.Name)
+	}
+
+	return &EntitySnapshot{
+		Id:          e.Id,
+		ParentId:    e.ParentId,
+		Code:        e.Code,
+		Name:        e.Name,
+		Description: e.Description,
+		Images:      append([]string{}, e.Images...),
+		Tags:        tags,
+		Attributes:  e.Attributes,
+		Quantity:    e.Quantity,
+		Unit:        e.Unit,
+		MinQuantity: e.MinQuantity,
+	}
+}
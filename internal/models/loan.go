@@ -0,0 +1,36 @@
+package models
+
+import (
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Loan
+// records an entity lent to a borrower. A loan is active until ReturnedAt is set,
+// the partial unique index allows only one active loan per entity.
+type Loan struct {
+	Id           string     `json:"id" gorm:"primaryKey"`
+	WorkspaceId  string     `json:"workspace_id" gorm:"index"`
+	EntityId     string     `json:"entity_id" gorm:"not null;index;uniqueIndex:idx_loans_active_entity,where:returned_at IS NULL"`
+	Entity       *Entity    `json:"entity,omitempty" gorm:"foreignKey:EntityId"`
+	Borrower     string     `json:"borrower" gorm:"not null"`
+	Note         string     `json:"note"`
+	DueAt        *time.Time `json:"due_at" gorm:"index"`
+	CheckedOutBy string     `json:"checked_out_by"`
+	CheckedInBy  *string    `json:"checked_in_by"`
+	CheckedOutAt time.Time  `json:"checked_out_at" gorm:"autoCreateTime"`
+	ReturnedAt   *time.Time `json:"returned_at" gorm:"index"`
+	Contents     []*Entity  `json:"contents,omitempty" gorm:"-"` // Descendants that left together with the entity
+}
+
+////////////////////////////////////////////////
+// DB Hook methods
+////////////////////////////////////////////////
+
+func (l *Loan) BeforeCreate(tx *gorm.DB) (err error) {
+	if l.Id == "" {
+		l.Id = cuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+// Role
+// is what a user may do in a workspace, or in a subtree of it when granted on an entity.
+// Each role includes the ones below it: viewers read, editors also change entities,
+// owners also manage members, grants and the attribute schema.
+type Role string
+
+const (
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleOwner  Role = "owner"
+)
+
+var ErrInvalidRole = errors.New("role must be viewer, editor or owner")
+
+// roleRank orders the roles, unknown roles rank below viewer and allow nothing
+var roleRank = map[Role]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleOwner:  3,
+}
+
+func ParseRole(raw string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(raw)))
+	if _, ok := roleRank[role]; !ok {
+		return "", ErrInvalidRole
+	}
+	return role, nil
+}
+
+// Allows reports whether the role includes the required one
+func (r Role) Allows(required Role) bool {
+	rank, ok := roleRank[r]
+	return ok && rank >= roleRank[required]
+}
+
+// HighestRole returns the strongest of the given roles, or an empty role when there is none
+func HighestRole(roles ...Role) Role {
+	var highest Role
+	for _, role := range roles {
+		if roleRank[role] > roleRank[highest] {
+			highest = role
+		}
+	}
+	return highest
+}
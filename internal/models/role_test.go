@@ -0,0 +1,45 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRole(t *testing.T) {
+	role, err := ParseRole(" Editor ")
+	if err != nil || role != RoleEditor {
+		t.Errorf("expected editor, got %q (%v)", role, err)
+	}
+	if _, err := ParseRole("admin"); !errors.Is(err, ErrInvalidRole) {
+		t.Errorf("expected ErrInvalidRole, got %v", err)
+	}
+}
+
+func TestRoleAllows(t *testing.T) {
+	cases := []struct {
+		role     Role
+		required Role
+		allowed  bool
+	}{
+		{RoleOwner, RoleEditor, true},
+		{RoleEditor, RoleEditor, true},
+		{RoleViewer, RoleEditor, false},
+		{RoleEditor, RoleOwner, false},
+		{"", RoleViewer, false},
+	}
+
+	for _, c := range cases {
+		if got := c.role.Allows(c.required); got != c.allowed {
+			t.Errorf("%q allows %q: expected %v, got %v", c.role, c.required, c.allowed, got)
+		}
+	}
+}
+
+func TestHighestRole(t *testing.T) {
+	if got := HighestRole(RoleViewer, RoleOwner, RoleEditor); got != RoleOwner {
+		t.Errorf("expected owner, got %q", got)
+	}
+	if got := HighestRole(); got != "" {
+		t.Errorf("expected no role, got %q", got)
+	}
+}
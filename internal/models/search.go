@@ -0,0 +1,7 @@
+package models
+
+type SearchHit struct {
+	Entity    *Entity   `json:"entity"`
+	Rank      float64   `json:"rank"`
+	Ancestors []*Entity `json:"ancestors"` // From the top level down to the direct parent
+}
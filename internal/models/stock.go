@@ -0,0 +1,28 @@
+package models
+
+import (
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// StockAdjustment records a single change of an entity's quantity
+type StockAdjustment struct {
+	Id        string    `json:"id" gorm:"primaryKey"`
+	EntityId  string    `json:"entity_id" gorm:"index;not null"`
+	Delta     int64     `json:"delta"`
+	Quantity  int64     `json:"quantity"` // Quantity after the adjustment
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+////////////////////////////////////////////////
+// DB Hook methods
+////////////////////////////////////////////////
+
+func (a *StockAdjustment) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.Id == "" {
+		a.Id = cuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// StringList
+// is a list of strings that is stored as a text[] on Postgres and as a JSON array on
+// databases without array columns, e.g. SQLite. Both forms are read back on either.
+type StringList []string
+
+func (StringList) GormDataType() string {
+	return "string_list"
+}
+
+func (StringList) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if db.Dialector.Name() == "postgres" {
+		return "text[]"
+	}
+	return "text"
+}
+
+func (l StringList) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
+	if db.Dialector.Name() == "postgres" {
+		return clause.Expr{SQL: "?", Vars: []any{pq.StringArray(l)}}
+	}
+
+	data, err := json.Marshal(append([]string{}, l...))
+	if err != nil {
+		_ = db.AddError(err)
+	}
+	return clause.Expr{SQL: "?", Vars: []any{string(data)}}
+}
+
+func (l *StringList) Scan(src any) error {
+	var raw []byte
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into a StringList", src)
+	}
+
+	if len(raw) > 0 && raw[0] == '[' {
+		var list []string
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return err
+		}
+		*l = list
+		return nil
+	}
+
+	var array pq.StringArray
+	if err := array.Scan(raw); err != nil {
+		return err
+	}
+	*l = StringList(array)
+	return nil
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStringListScansBothForms(t *testing.T) {
+	expected := StringList{"ws/a_1", "b,2"}
+
+	for _, src := range []any{`{ws/a_1,"b,2"}`, []byte(`["ws/a_1","b,2"]`)} {
+		var l StringList
+		if err := l.Scan(src); err != nil {
+			t.Fatalf("unable to scan %v: %v", src, err)
+		}
+		if !reflect.DeepEqual(l, expected) {
+			t.Errorf("scanning %v: expected %v, got %v", src, expected, l)
+		}
+	}
+
+	var l StringList
+	if err := l.Scan(nil); err != nil || l != nil {
+		t.Errorf("expected NULL to scan into a nil list, got %v (%v)", l, err)
+	}
+}
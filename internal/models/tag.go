@@ -0,0 +1,47 @@
+package models
+
+import (
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+type Tag struct {
+	Id          string    `json:"id" gorm:"primaryKey"`
+	WorkspaceId string    `json:"workspace_id" gorm:"uniqueIndex:idx_tags_workspace_name,priority:1"`
+	Name        string    `json:"name" gorm:"uniqueIndex:idx_tags_workspace_name,priority:2;not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+////////////////////////////////////////////////
+// DB Hook methods
+////////////////////////////////////////////////
+
+func (t *Tag) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.Id == "" {
+		t.Id = cuid.New()
+	}
+	return nil
+}
+
+////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////
+
+// NormalizeTagNames
+// trims the names and drops empty and repeated ones, keeping the original order.
+func NormalizeTagNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	res := make([]string, 0, len(names))
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		if n == "" || seen[n] {
+			continue
+		}
+		seen[n] = true
+		res = append(res, n)
+	}
+	return res
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/lucsky/cuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+const (
+	MinPasswordLength = 8
+	maxPasswordLength = 72 // bcrypt ignores everything past 72 bytes
+	maxUsernameLength = 64
+	tokenBytes        = 32
+)
+
+var (
+	ErrInvalidUsername = errors.New("usernames must be 1 to 64 characters without spaces")
+	ErrInvalidPassword = errors.New("passwords must be 8 to 72 bytes long")
+)
+
+type User struct {
+	Id           string    `json:"id" gorm:"primaryKey"`
+	Username     string    `json:"username" gorm:"uniqueIndex;not null"`
+	PasswordHash string    `json:"-" gorm:"not null"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// AuthToken
+// is a login session. Only the SHA-256 of the token is stored, the token itself
+// is handed out once by NewAuthToken.
+type AuthToken struct {
+	Id        string    `json:"id" gorm:"primaryKey"`
+	UserId    string    `json:"user_id" gorm:"index;not null"`
+	User      *User     `json:"-" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
+	TokenHash string    `json:"-" gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+////////////////////////////////////////////////
+// DB Hook methods
+////////////////////////////////////////////////
+
+func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
+	if u.Id == "" {
+		u.Id = cuid.New()
+	}
+	return nil
+}
+
+func (t *AuthToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.Id == "" {
+		t.Id = cuid.New()
+	}
+	return nil
+}
+
+////////////////////////////////////////////////
+// Constructors
+////////////////////////////////////////////////
+
+// NewUser
+// validates the credentials and hashes the password.
+func NewUser(username string, password string) (*User, error) {
+	username = NormalizeUsername(username)
+	if username == "" || len(username) > maxUsernameLength || strings.ContainsAny(username, " \t\r\n") {
+		return nil, ErrInvalidUsername
+	}
+
+	u := &User{Username: username}
+	if err := u.SetPassword(password); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// NewAuthToken
+// returns a fresh random token and the record to store for it.
+func NewAuthToken(userId string, ttl time.Duration) (string, *AuthToken, error) {
+	raw := make([]byte, tokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	return token, &AuthToken{
+		UserId:    userId,
+		TokenHash: HashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////
+
+func (u *User) SetPassword(password string) error {
+	if len(password) < MinPasswordLength || len(password) > maxPasswordLength {
+		return ErrInvalidPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.PasswordHash = string(hash)
+	return nil
+}
+
+func (u *User) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
+}
+
+// NormalizeUsername
+// usernames are matched case-insensitively.
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewUserHashesPassword(t *testing.T) {
+	u, err := NewUser(" Alice ", "correct horse")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if u.Username != "alice" {
+		t.Errorf("expected the username to be normalized, got %q", u.Username)
+	}
+	if u.PasswordHash == "correct horse" {
+		t.Errorf("expected the password to be hashed")
+	}
+	if !u.CheckPassword("correct horse") || u.CheckPassword("wrong horse") {
+		t.Errorf("expected only the original password to match")
+	}
+}
+
+func TestNewUserRejectsInvalidCredentials(t *testing.T) {
+	if _, err := NewUser("two words", "correct horse"); !errors.Is(err, ErrInvalidUsername) {
+		t.Errorf("expected ErrInvalidUsername, got %v", err)
+	}
+	if _, err := NewUser("alice", "short"); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("expected ErrInvalidPassword, got %v", err)
+	}
+}
+
+func TestNewAuthTokenStoresHashOnly(t *testing.T) {
+	token, record, err := NewAuthToken("user", time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if record.TokenHash == token || record.TokenHash != HashToken(token) {
+		t.Errorf("expected the record to hold the hash of the token")
+	}
+	if !record.ExpiresAt.After(time.Now()) {
+		t.Errorf("expected the token to expire in the future")
+	}
+}
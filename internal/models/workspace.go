@@ -0,0 +1,60 @@
+package models
+
+import (
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// DefaultWorkspaceName is given to the workspace that data from before workspaces is moved into
+const DefaultWorkspaceName = "Default"
+
+// Workspace
+// holds an isolated entity tree together with its tags, attribute schema and loans.
+type Workspace struct {
+	Id        string    `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// WorkspaceMember grants a user access to a workspace
+type WorkspaceMember struct {
+	WorkspaceId string     `json:"workspace_id" gorm:"primaryKey"`
+	UserId      string     `json:"user_id" gorm:"primaryKey;index"`
+	Workspace   *Workspace `json:"-" gorm:"foreignKey:WorkspaceId;constraint:OnDelete:CASCADE"`
+	User        *User      `json:"user,omitempty" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
+	Role        Role       `json:"role" gorm:"not null;default:viewer"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// SubtreeGrant
+// raises the role of a member on an entity and everything below it, e.g. a viewer of the
+// workspace who may edit everything under "Lab Cabinet". Grants never lower a role.
+type SubtreeGrant struct {
+	Id          string    `json:"id" gorm:"primaryKey"`
+	WorkspaceId string    `json:"workspace_id" gorm:"index;not null"`
+	UserId      string    `json:"user_id" gorm:"uniqueIndex:idx_subtree_grants_user_entity;not null"`
+	User        *User     `json:"user,omitempty" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
+	EntityId    string    `json:"entity_id" gorm:"uniqueIndex:idx_subtree_grants_user_entity;index;not null"`
+	Role        Role      `json:"role" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+////////////////////////////////////////////////
+// DB Hook methods
+////////////////////////////////////////////////
+
+func (w *Workspace) BeforeCreate(tx *gorm.DB) (err error) {
+	if w.Id == "" {
+		w.Id = cuid.New()
+	}
+	return nil
+}
+
+func (g *SubtreeGrant) BeforeCreate(tx *gorm.DB) (err error) {
+	if g.Id == "" {
+		g.Id = cuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package objectstore
+
+import (
+	"Backend/internal/thumbnail"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalAdapter
+// keeps objects as files below a directory, names map to paths relative to it.
+type LocalAdapter struct {
+	root string
+}
+
+func NewLocalAdapter(root string) (*LocalAdapter, error) {
+	if root == "" {
+		return nil, errors.New("OBJECT_STORE_PATH is required for the local object store")
+	}
+
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(abs, 0o750); err != nil {
+		return nil, err
+	}
+
+	return &LocalAdapter{root: abs}, nil
+}
+
+func (l *LocalAdapter) UploadImage(ctx context.Context, filename string, img []byte) error {
+	return l.UploadObject(ctx, filename, img, "image/jpeg")
+}
+
+// UploadObject
+// writes to a temporary file first, so that readers never see a partially written object.
+// The content type is not kept, readers detect it from the content.
+func (l *LocalAdapter) UploadObject(ctx context.Context, name string, data []byte, contentType string) error {
+	target, err := l.path(name)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return err
+	}
+
+	log.Printf("Uploaded object %s [size: %v]", name, len(data))
+	return nil
+}
+
+func (l *LocalAdapter) UploadThumbnail(ctx context.Context, t *thumbnail.Thumbnails) error {
+	return uploadThumbnailSizes(ctx, l, t)
+}
+
+func (l *LocalAdapter) RetrieveImage(ctx context.Context, name string) (io.ReadCloser, error) {
+	target, err := l.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(target)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (l *LocalAdapter) DeleteImage(ctx context.Context, name string) error {
+	target, err := l.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	log.Printf("Deleted image %s", name)
+	return nil
+}
+
+func (l *LocalAdapter) DeleteThumbnail(ctx context.Context, baseName string) error {
+	return deleteThumbnailSizes(ctx, l, baseName)
+}
+
+// ListObjects
+// walks the directory, files of uploads still being written are left out.
+func (l *LocalAdapter) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var infos []ObjectInfo
+
+	err := filepath.WalkDir(l.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.root, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		infos = append(infos, ObjectInfo{Name: name, Size: info.Size(), LastModified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// path
+// maps an object name to a file below the root, names that would escape it are rejected.
+func (l *LocalAdapter) path(name string) (string, error) {
+	if name == "" || strings.Contains(name, "\\") || !filepath.IsLocal(filepath.FromSlash(name)) {
+		return "", ErrInvalidObjectName
+	}
+	return filepath.Join(l.root, filepath.FromSlash(name)), nil
+}
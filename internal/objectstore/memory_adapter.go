@@ -0,0 +1,85 @@
+package objectstore
+
+import (
+	"Backend/internal/thumbnail"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryAdapter
+// keeps objects in memory, everything is lost on restart. Meant for tests and demos.
+type MemoryAdapter struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+type memoryObject struct {
+	data         []byte
+	lastModified time.Time
+}
+
+func NewMemoryAdapter() *MemoryAdapter {
+	return &MemoryAdapter{objects: make(map[string]memoryObject)}
+}
+
+func (m *MemoryAdapter) UploadImage(ctx context.Context, filename string, img []byte) error {
+	return m.UploadObject(ctx, filename, img, "image/jpeg")
+}
+
+func (m *MemoryAdapter) UploadObject(ctx context.Context, name string, data []byte, contentType string) error {
+	if name == "" {
+		return ErrInvalidObjectName
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.objects[name] = memoryObject{data: bytes.Clone(data), lastModified: time.Now()}
+	return nil
+}
+
+func (m *MemoryAdapter) UploadThumbnail(ctx context.Context, t *thumbnail.Thumbnails) error {
+	return uploadThumbnailSizes(ctx, m, t)
+}
+
+func (m *MemoryAdapter) RetrieveImage(ctx context.Context, name string) (io.ReadCloser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	obj, ok := m.objects[name]
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+
+	// Objects are replaced and never modified in place, the reader can share the slice
+	return io.NopCloser(bytes.NewReader(obj.data)), nil
+}
+
+func (m *MemoryAdapter) DeleteImage(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.objects, name)
+	return nil
+}
+
+func (m *MemoryAdapter) DeleteThumbnail(ctx context.Context, baseName string) error {
+	return deleteThumbnailSizes(ctx, m, baseName)
+}
+
+func (m *MemoryAdapter) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	infos := make([]ObjectInfo, 0, len(m.objects))
+	for name, obj := range m.objects {
+		if strings.HasPrefix(name, prefix) {
+			infos = append(infos, ObjectInfo{Name: name, Size: int64(len(obj.data)), LastModified: obj.lastModified})
+		}
+	}
+	return infos, nil
+}
@@ -54,30 +54,32 @@ func (m *MinioAdapter) UpsertBucket(ctx context.Context, name string) error {
 }
 
 func (m *MinioAdapter) UploadImage(ctx context.Context, filename string, img []byte) error {
+	return m.UploadObject(ctx, filename, img, "image/jpeg")
+}
+
+func (m *MinioAdapter) UploadObject(ctx context.Context, name string, data []byte, contentType string) error {
 
 	if err := m.UpsertBucket(ctx, m.bucket); err != nil {
 		return err
 	}
 
-	contentType := "content/jpeg"
-
 	info, err := m.client.PutObject(
 		ctx,
 		m.bucket,
-		filename,
-		bytes.NewReader(img),
-		int64(len(img)),
+		name,
+		bytes.NewReader(data),
+		int64(len(data)),
 		minio.PutObjectOptions{
 			ContentType: contentType,
 		},
 	)
 
 	if err != nil {
-		log.Printf("Unable to upload thumbnail: %v", err)
+		log.Printf("Unable to upload object: %v", err)
 		return err
 	}
 
-	log.Printf("Uploaded image %s [size: %v]", filename, info.Size)
+	log.Printf("Uploaded object %s [size: %v]", name, info.Size)
 	return nil
 }
 
@@ -108,6 +110,9 @@ func (m *MinioAdapter) RetrieveImage(ctx context.Context, name string) (io.ReadC
 
 	_, err := m.client.StatObject(ctx, m.bucket, name, minio.StatObjectOptions{})
 	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrObjectNotFound
+		}
 		return nil, err
 	}
 
@@ -118,3 +123,33 @@ func (m *MinioAdapter) RetrieveImage(ctx context.Context, name string) (io.ReadC
 
 	return obj, nil
 }
+
+func (m *MinioAdapter) DeleteImage(ctx context.Context, name string) error {
+	if err := m.client.RemoveObject(ctx, m.bucket, name, minio.RemoveObjectOptions{}); err != nil {
+		return err
+	}
+
+	log.Printf("Deleted image %s", name)
+	return nil
+}
+
+func (m *MinioAdapter) DeleteThumbnail(ctx context.Context, baseName string) error {
+	return deleteThumbnailSizes(ctx, m, baseName)
+}
+
+func (m *MinioAdapter) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var infos []ObjectInfo
+
+	for obj := range m.client.ListObjects(ctx, m.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			// The bucket is only created with the first upload
+			if minio.ToErrorResponse(obj.Err).Code == "NoSuchBucket" {
+				return nil, nil
+			}
+			return nil, obj.Err
+		}
+		infos = append(infos, ObjectInfo{Name: obj.Key, Size: obj.Size, LastModified: obj.LastModified})
+	}
+
+	return infos, nil
+}
@@ -0,0 +1,92 @@
+package objectstore
+
+import (
+	"Backend/internal/env"
+	"Backend/internal/thumbnail"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	BackendMinio  = "minio"
+	BackendLocal  = "local"
+	BackendMemory = "memory"
+)
+
+var (
+	ErrObjectNotFound    = errors.New("object not found")
+	ErrInvalidObjectName = errors.New("invalid object name")
+)
+
+var (
+	_ ObjectStore = (*MinioAdapter)(nil)
+	_ ObjectStore = (*LocalAdapter)(nil)
+	_ ObjectStore = (*MemoryAdapter)(nil)
+)
+
+// ObjectStore
+// holds entity images, their thumbnails and cached labels, keyed by name.
+// Names may contain slashes, e.g. the workspace prefix of images.
+type ObjectStore interface {
+	UploadImage(ctx context.Context, filename string, img []byte) error
+	UploadObject(ctx context.Context, name string, data []byte, contentType string) error
+	UploadThumbnail(ctx context.Context, t *thumbnail.Thumbnails) error
+	// RetrieveImage fails with ErrObjectNotFound for unknown names
+	RetrieveImage(ctx context.Context, name string) (io.ReadCloser, error)
+	DeleteImage(ctx context.Context, name string) error
+	DeleteThumbnail(ctx context.Context, baseName string) error
+	// ListObjects returns every object whose name starts with the prefix, in no particular order
+	ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error)
+}
+
+type ObjectInfo struct {
+	Name         string
+	Size         int64
+	LastModified time.Time
+}
+
+// NewObjectStore
+// creates the backend named by OBJECT_STORE: MinIO, a directory on the local disk
+// or memory, which loses everything on restart and is meant for tests and demos.
+func NewObjectStore() (ObjectStore, error) {
+	envVars := env.GetStaticEnv()
+
+	switch envVars.ObjectStore {
+	case BackendMinio:
+		return NewMinioAdapter(), nil
+	case BackendLocal:
+		return NewLocalAdapter(envVars.ObjectStorePath)
+	case BackendMemory:
+		return NewMemoryAdapter(), nil
+	default:
+		return nil, fmt.Errorf("unknown object store %q, use minio, local or memory", envVars.ObjectStore)
+	}
+}
+
+// uploadThumbnailSizes
+// uploads every size of the thumbnails one after the other, for backends without network latency.
+func uploadThumbnailSizes(ctx context.Context, store ObjectStore, t *thumbnail.Thumbnails) error {
+	for name, img := range *t.GetImageDataMap() {
+		if err := store.UploadImage(ctx, name, img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteThumbnailSizes
+// deletes every size of the thumbnails, carrying on past failures and returning the last one.
+func deleteThumbnailSizes(ctx context.Context, store ObjectStore, baseName string) error {
+	var errFlag error
+
+	for _, name := range thumbnail.ImageNamesFromBaseName(baseName) {
+		if err := store.DeleteImage(ctx, name); err != nil {
+			errFlag = err
+		}
+	}
+
+	return errFlag
+}
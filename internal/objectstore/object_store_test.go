@@ -0,0 +1,79 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func testRoundTrip(t *testing.T, store ObjectStore) {
+	ctx := context.Background()
+
+	if _, err := store.RetrieveImage(ctx, "ws/missing.jpeg"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("expected ErrObjectNotFound for a missing object, got %v", err)
+	}
+
+	if err := store.UploadImage(ctx, "ws/a.jpeg", []byte("first")); err != nil {
+		t.Fatalf("unable to upload: %v", err)
+	}
+	if err := store.UploadImage(ctx, "ws/a.jpeg", []byte("second")); err != nil {
+		t.Fatalf("unable to overwrite: %v", err)
+	}
+
+	r, err := store.RetrieveImage(ctx, "ws/a.jpeg")
+	if err != nil {
+		t.Fatalf("unable to retrieve: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	_ = r.Close()
+	if string(data) != "second" {
+		t.Errorf("expected the latest upload, got %q", data)
+	}
+
+	if err := store.UploadImage(ctx, "other/b.jpeg", []byte("b")); err != nil {
+		t.Fatalf("unable to upload: %v", err)
+	}
+	infos, err := store.ListObjects(ctx, "ws/")
+	if err != nil {
+		t.Fatalf("unable to list: %v", err)
+	}
+	if len(infos) != 1 || infos[0].Name != "ws/a.jpeg" || infos[0].Size != int64(len("second")) {
+		t.Errorf("expected only ws/a.jpeg below ws/, got %+v", infos)
+	}
+
+	if err := store.DeleteImage(ctx, "ws/a.jpeg"); err != nil {
+		t.Fatalf("unable to delete: %v", err)
+	}
+	if err := store.DeleteImage(ctx, "ws/a.jpeg"); err != nil {
+		t.Errorf("expected deleting a missing object to succeed, got %v", err)
+	}
+	if _, err := store.RetrieveImage(ctx, "ws/a.jpeg"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("expected ErrObjectNotFound after delete, got %v", err)
+	}
+}
+
+func TestMemoryAdapter(t *testing.T) {
+	testRoundTrip(t, NewMemoryAdapter())
+}
+
+func TestLocalAdapter(t *testing.T) {
+	store, err := NewLocalAdapter(t.TempDir())
+	if err != nil {
+		t.Fatalf("unable to create store: %v", err)
+	}
+	testRoundTrip(t, store)
+}
+
+func TestLocalAdapterRejectsEscapingNames(t *testing.T) {
+	store, err := NewLocalAdapter(t.TempDir())
+	if err != nil {
+		t.Fatalf("unable to create store: %v", err)
+	}
+
+	for _, name := range []string{"../outside.jpeg", "/etc/passwd", "ws/../../x", ""} {
+		if err := store.UploadImage(context.Background(), name, []byte("x")); !errors.Is(err, ErrInvalidObjectName) {
+			t.Errorf("expected ErrInvalidObjectName for %q, got %v", name, err)
+		}
+	}
+}
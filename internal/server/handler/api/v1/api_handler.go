@@ -1,13 +1,91 @@
 package v1
 
 import "net/http"
+import "Backend/internal/models"
 import "Backend/internal/server/handler/api/v1/endpoints"
+import "Backend/internal/server/middleware"
 
 func Router() *http.ServeMux {
 	router := http.NewServeMux()
 
-	router.HandleFunc("POST /create", http.HandlerFunc(endpoints.Create))
-	router.HandleFunc("GET /query", http.HandlerFunc(endpoints.Query))
+	// Roles are checked on the workspace, or on the entity of the route including grants on its ancestors
+	entity := middleware.EntityFromPath("id")
+	viewer := middleware.RequireRole(models.RoleViewer)
+	editor := middleware.RequireRole(models.RoleEditor)
+	owner := middleware.RequireRole(models.RoleOwner)
+	entityViewer := middleware.RequireRole(models.RoleViewer, entity)
+	entityEditor := middleware.RequireRole(models.RoleEditor, entity)
+	entityOwner := middleware.RequireRole(models.RoleOwner, entity)
+	// Tags belong to the workspace, editors of a subtree only attach existing ones
+	tagCreator := middleware.RestrictTagCreation(models.RoleEditor)
+
+	router.HandleFunc("POST /login", http.HandlerFunc(endpoints.Login))
+	router.HandleFunc("POST /logout", http.HandlerFunc(endpoints.Logout))
+	router.HandleFunc("GET /me", http.HandlerFunc(endpoints.Me))
+
+	// Member routes check the role in the workspace of the path, not in the one of the request
+	workspaceViewer := middleware.RequireWorkspaceRole(models.RoleViewer)
+	workspaceOwner := middleware.RequireWorkspaceRole(models.RoleOwner)
+	workspaceOwnerOrSelf := middleware.RequireWorkspaceRole(models.RoleOwner, middleware.WorkspaceRoleExceptSelf("userId"))
+
+	router.HandleFunc("GET /workspaces", http.HandlerFunc(endpoints.ListWorkspaces))
+	router.HandleFunc("POST /workspaces", http.HandlerFunc(endpoints.CreateWorkspace))
+	router.Handle("GET /workspaces/{id}/members", workspaceViewer(http.HandlerFunc(endpoints.ListWorkspaceMembers)))
+	router.Handle("POST /workspaces/{id}/members", workspaceOwner(http.HandlerFunc(endpoints.AddWorkspaceMember)))
+	router.Handle("PATCH /workspaces/{id}/members/{userId}", workspaceOwner(http.HandlerFunc(endpoints.UpdateMemberRole)))
+	router.Handle("DELETE /workspaces/{id}/members/{userId}", workspaceOwnerOrSelf(http.HandlerFunc(endpoints.RemoveWorkspaceMember)))
+
+	router.Handle("POST /create", middleware.RequireRole(
+		models.RoleEditor,
+		middleware.EntityFromForm("parent_id", endpoints.FormMaxMemory),
+	)(tagCreator(http.HandlerFunc(endpoints.Create))))
+	router.Handle("GET /query", viewer(http.HandlerFunc(endpoints.Query)))
+	router.Handle("GET /search", viewer(http.HandlerFunc(endpoints.Search)))
+	router.Handle("GET /resolve/{code}", viewer(http.HandlerFunc(endpoints.Resolve)))
+	router.Handle("PATCH /entities/{id}", entityEditor(http.HandlerFunc(endpoints.Update)))
+	router.Handle("DELETE /entities/{id}", entityEditor(http.HandlerFunc(endpoints.Delete)))
+	router.Handle("POST /entities/{id}/move", middleware.RequireRole(
+		models.RoleEditor,
+		entity,
+		middleware.EntityFromJson("parent_id"),
+	)(http.HandlerFunc(endpoints.Move)))
+	router.Handle("POST /entities/{id}/adjust", entityEditor(http.HandlerFunc(endpoints.Adjust)))
+	router.Handle("POST /entities/{id}/checkout", entityEditor(http.HandlerFunc(endpoints.CheckOut)))
+	router.Handle("POST /entities/{id}/checkin", entityEditor(http.HandlerFunc(endpoints.CheckIn)))
+	router.Handle("GET /entities/{id}/path", entityViewer(http.HandlerFunc(endpoints.Path)))
+	router.Handle("GET /entities/{id}/tree", entityViewer(http.HandlerFunc(endpoints.Tree)))
+	router.Handle("GET /entities/{id}/history", entityViewer(http.HandlerFunc(endpoints.History)))
+	router.Handle("GET /entities/{id}/location", entityViewer(http.HandlerFunc(endpoints.Location)))
+	router.Handle("GET /entities/{id}/label.png", entityViewer(http.HandlerFunc(endpoints.LabelPng)))
+	router.Handle("GET /entities/{id}/label.svg", entityViewer(http.HandlerFunc(endpoints.LabelSvg)))
+	router.Handle("POST /entities/{id}/tags", entityEditor(tagCreator(http.HandlerFunc(endpoints.AttachTags))))
+	router.Handle("DELETE /entities/{id}/tags/{tagId}", entityEditor(http.HandlerFunc(endpoints.DetachTag)))
+	router.Handle("GET /entities/{id}/grants", entityOwner(http.HandlerFunc(endpoints.ListGrants)))
+	router.Handle("PUT /entities/{id}/grants/{userId}", entityOwner(http.HandlerFunc(endpoints.PutGrant)))
+	router.Handle("DELETE /entities/{id}/grants/{userId}", entityOwner(http.HandlerFunc(endpoints.RevokeGrant)))
+
+	router.Handle("GET /low-stock", viewer(http.HandlerFunc(endpoints.LowStock)))
+
+	router.Handle("GET /loans", viewer(http.HandlerFunc(endpoints.Loans)))
+	router.Handle("GET /loans/overdue", viewer(http.HandlerFunc(endpoints.OverdueLoans)))
+
+	router.Handle("POST /labels/sheet", viewer(http.HandlerFunc(endpoints.LabelSheet)))
+
+	router.Handle("GET /tags", viewer(http.HandlerFunc(endpoints.ListTags)))
+	router.Handle("POST /tags", editor(http.HandlerFunc(endpoints.CreateTag)))
+	router.Handle("PATCH /tags/{id}", editor(http.HandlerFunc(endpoints.RenameTag)))
+
+	router.Handle("GET /attributes", viewer(http.HandlerFunc(endpoints.ListAttributeSchema)))
+	router.Handle("PUT /attributes/{key}", owner(http.HandlerFunc(endpoints.PutAttributeDefinition)))
+	router.Handle("DELETE /attributes/{key}", owner(http.HandlerFunc(endpoints.DeleteAttributeDefinition)))
+
+	router.Handle("GET /api-keys", owner(http.HandlerFunc(endpoints.ListApiKeys)))
+	router.Handle("POST /api-keys", owner(http.HandlerFunc(endpoints.CreateApiKey)))
+	router.Handle("DELETE /api-keys/{id}", owner(http.HandlerFunc(endpoints.RevokeApiKey)))
+
+	router.Handle("GET /trash", viewer(http.HandlerFunc(endpoints.Trash)))
+	router.Handle("POST /trash/{id}/restore", entityEditor(http.HandlerFunc(endpoints.Restore)))
+	router.Handle("POST /trash/purge", owner(http.HandlerFunc(endpoints.Purge)))
 
 	return router
 }
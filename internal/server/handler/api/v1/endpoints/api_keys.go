@@ -0,0 +1,122 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/models"
+	"Backend/internal/server/middleware"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const maxApiKeyNameLength = 100
+
+type apiKeyRequest struct {
+	Name      string     `json:"name"`
+	Scope     string     `json:"scope"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// createdApiKey is the only response that carries the key itself
+type createdApiKey struct {
+	*models.ApiKey
+	Key string `json:"key"`
+}
+
+func ListApiKeys(w http.ResponseWriter, r *http.Request) {
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	keys, err := db.QueryApiKeys(r.Context())
+	if err != nil {
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, http.StatusOK, keys)
+}
+
+// CreateApiKey
+// expects a JSON body {"name": "Scanner 1", "scope": "read", "expires_at": "<RFC 3339 time>"}
+// for a key of the current workspace, scope defaults to read and expires_at is optional.
+// The key is part of this response only, it cannot be retrieved later.
+func CreateApiKey(w http.ResponseWriter, r *http.Request) {
+
+	var req apiKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Request body is not valid JSON, expires_at must be an RFC 3339 time", http.StatusBadRequest)
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxApiKeyNameLength {
+		http.Error(w, "Key name is required and at most 100 characters long", http.StatusBadRequest)
+		return
+	}
+
+	scope := models.ApiKeyScopeRead
+	if req.Scope != "" {
+		parsed, err := models.ParseApiKeyScope(req.Scope)
+		if err != nil {
+			http.Error(w, "Scope must be read or write", http.StatusBadRequest)
+			return
+		}
+		scope = parsed
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		http.Error(w, "expires_at must be in the future", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	record, key, err := db.CreateApiKey(r.Context(), name, scope, user.Id, req.ExpiresAt)
+	if err != nil {
+		log.Printf("[Error] Unable to create API key, %v", err)
+		http.Error(w, "Unable to create API key", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJson(w, http.StatusCreated, createdApiKey{ApiKey: record, Key: key})
+}
+
+func RevokeApiKey(w http.ResponseWriter, r *http.Request) {
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	if err := db.RevokeApiKey(r.Context(), r.PathValue("id")); err != nil {
+		if errors.Is(err, database.ErrApiKeyNotFound) {
+			http.Error(w, "API key not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
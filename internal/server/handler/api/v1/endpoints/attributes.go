@@ -0,0 +1,95 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/models"
+	"Backend/internal/server/middleware"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+type attributeDefinitionRequest struct {
+	Type    models.AttributeType `json:"type"`
+	Options []string             `json:"options"`
+}
+
+// ListAttributeSchema
+// returns every attribute definition ordered by key.
+func ListAttributeSchema(w http.ResponseWriter, r *http.Request) {
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	defs, err := db.QueryAttributeSchema(r.Context())
+	if err != nil {
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, http.StatusOK, defs)
+}
+
+// PutAttributeDefinition
+// creates or replaces the definition of an attribute key, expects a JSON body
+// {"type": "string|number|date|bool|enum", "options": [...]} with options only for enums.
+func PutAttributeDefinition(w http.ResponseWriter, r *http.Request) {
+
+	var req attributeDefinitionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Request body is not valid JSON", http.StatusBadRequest)
+		return
+	}
+
+	def := &models.AttributeDefinition{
+		Key:     r.PathValue("key"),
+		Type:    req.Type,
+		Options: req.Options,
+	}
+	if err := def.Check(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	if err := db.UpsertAttributeDefinition(r.Context(), def); err != nil {
+		log.Println(err)
+		http.Error(w, "Unable to store attribute definition", http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, http.StatusOK, def)
+}
+
+// DeleteAttributeDefinition
+// removes the definition of a key, values already stored under it are kept.
+func DeleteAttributeDefinition(w http.ResponseWriter, r *http.Request) {
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	if err := db.DeleteAttributeDefinition(r.Context(), r.PathValue("key")); err != nil {
+		if errors.Is(err, database.ErrAttributeNotFound) {
+			http.Error(w, "Attribute definition not found", http.StatusNotFound)
+			return
+		}
+		log.Println(err)
+		http.Error(w, "Unable to delete attribute definition", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,126 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/env"
+	"Backend/internal/models"
+	"Backend/internal/server/middleware"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type loginResponse struct {
+	Token     string       `json:"token"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	User      *models.User `json:"user"`
+}
+
+// Login
+// exchanges {"username": "", "password": ""} for a token to send as "Authorization: Bearer <token>".
+// While images are private the token is set as a cookie for /image/ too, so that <img> tags can load them.
+func Login(w http.ResponseWriter, r *http.Request) {
+
+	var req loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Request body is not valid JSON", http.StatusBadRequest)
+		return
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	e := env.GetStaticEnv()
+
+	token, record, err := db.Login(r.Context(), req.Username, req.Password, e.TokenTtl)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidCredentials) {
+			http.Error(w, "Invalid username or password", http.StatusUnauthorized)
+			return
+		}
+		log.Println(err)
+		http.Error(w, "Unable to log in", http.StatusInternalServerError)
+		return
+	}
+
+	if !e.ImagePublic {
+		http.SetCookie(w, imageCookie(r, token, record.ExpiresAt))
+	}
+
+	writeJson(w, http.StatusOK, &loginResponse{
+		Token:     token,
+		ExpiresAt: record.ExpiresAt,
+		User:      record.User,
+	})
+}
+
+// Logout
+// revokes the token the request was made with.
+func Logout(w http.ResponseWriter, r *http.Request) {
+
+	token, ok := middleware.BearerToken(r)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	if models.IsApiKey(token) {
+		http.Error(w, "API keys cannot log out, revoke them instead", http.StatusBadRequest)
+		return
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	if err := db.Logout(r.Context(), token); err != nil {
+		log.Println(err)
+		http.Error(w, "Unable to log out", http.StatusInternalServerError)
+		return
+	}
+
+	http.SetCookie(w, imageCookie(r, "", time.Unix(0, 0)))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Me
+// returns the user the request is authenticated as, or the API key for requests made with one.
+func Me(w http.ResponseWriter, r *http.Request) {
+
+	if key, ok := middleware.GetApiKeyFromContext(r.Context()); ok {
+		writeJson(w, http.StatusOK, key)
+		return
+	}
+
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	writeJson(w, http.StatusOK, user)
+}
+
+func imageCookie(r *http.Request, token string, expires time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     middleware.AuthCookieName,
+		Value:    token,
+		Path:     "/image/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || strings.HasPrefix(env.GetStaticEnv().PublicBaseUrl, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	}
+}
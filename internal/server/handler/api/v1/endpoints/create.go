@@ -1,32 +1,19 @@
 package endpoints
 
 import (
+	"Backend/internal/database"
 	"Backend/internal/models"
 	"Backend/internal/server/middleware"
-	"Backend/internal/thumbnail"
-	"encoding/json"
 	"errors"
-	"fmt"
 	"github.com/lucsky/cuid"
 	"log"
-	"mime/multipart"
 	"net/http"
-	"sync"
 )
 
 func Create(w http.ResponseWriter, r *http.Request) {
 
 	// Parsing the request, assumes POST form-data
-	const maxMemory = 10 << 20 // 10 MB
-	if err := r.ParseMultipartForm(maxMemory); err != nil {
-		switch {
-		case errors.Is(err, http.ErrNotMultipart) || errors.Is(err, http.ErrMissingBoundary):
-			http.Error(w, "Form data not present or not multipart", http.StatusBadRequest)
-		case err.Error() == "multipart: NextPart: EOF":
-			http.Error(w, "No form data present", http.StatusBadRequest)
-		default:
-			http.Error(w, "Failed to parse form, 10MB limit exceeded", http.StatusRequestEntityTooLarge)
-		}
+	if !parseEntityForm(w, r) {
 		return
 	}
 
@@ -40,49 +27,63 @@ func Create(w http.ResponseWriter, r *http.Request) {
 	name := r.FormValue("name")
 	description := r.FormValue("description")
 	parentId := r.FormValue("parent_id")
+	tags := r.MultipartForm.Value["tags"]
+
+	code := ""
+	if raw := r.FormValue("code"); raw != "" {
+		normalized, err := models.NormalizeCode(raw)
+		if err != nil {
+			http.Error(w, "Invalid code, use 4 to 16 letters or digits", http.StatusBadRequest)
+			return
+		}
+		code = normalized
+	}
 
-	// Extract images
-	images := r.MultipartForm.File["images"]
-	thumbnails := make([]string, 0)
+	attrs, _, err := formAttributes(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	// Get Minio Object
-	objStore, ok := middleware.GetObjStoreFromContext(r.Context())
+	// Single items by default
+	quantity := int64(1)
+	if raw := r.FormValue("quantity"); raw != "" {
+		if quantity, err = parseQuantity(raw); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var minQuantity *int64
+	if raw := r.FormValue("min_quantity"); raw != "" {
+		threshold, err := parseQuantity(raw)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		minQuantity = &threshold
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unable to load ObjectStore instance", http.StatusInternalServerError)
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
 		return
 	}
 
-	var thErrFlag error
-	mut := sync.Mutex{}
-
-	wg := sync.WaitGroup{}
-	wg.Add(len(images))
-	for _, img := range images {
-		go func(img *multipart.FileHeader, _id string) {
-			imgBody, _ := img.Open()
-			t, err := thumbnail.NewThumbnailsFromMultipart(imgBody, _id)
-			if err != nil {
-				log.Printf("%v", err)
-				thErrFlag = err
-				return
-			}
-
-			if err := objStore.UploadThumbnail(r.Context(), t); err != nil {
-				thErrFlag = err
-				log.Printf("%v", err)
-				return
-			}
-
-			mut.Lock()
-			thumbnails = append(thumbnails, fmt.Sprintf("/image/v1/%s", t.GetImageBaseNameWithExt()))
-			mut.Unlock()
-
-			wg.Done()
-		}(img, id)
+	// Avoid uploading images for a form that is rejected anyway
+	if err := checkFormAttributes(r, db, attrs); err != nil {
+		if errors.Is(err, models.ErrInvalidAttribute) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		return
 	}
-	wg.Wait()
 
-	if thErrFlag != nil {
+	// Extract images
+	thumbnails, err := uploadFormImages(r, id)
+	if err != nil {
 		http.Error(w, "Unable to generate thumbnails", http.StatusInternalServerError)
 		return
 	}
@@ -93,24 +94,41 @@ func Create(w http.ResponseWriter, r *http.Request) {
 		models.EntityWithDescription(description),
 		models.EntityWithParentId(parentId),
 		models.EntityWithImages(thumbnails),
+		models.EntityWithTags(tags),
+		models.EntityWithCode(code),
+		models.EntityWithAttributes(attrs),
+		models.EntityWithQuantity(quantity),
+		models.EntityWithUnit(r.FormValue("unit")),
+		models.EntityWithMinQuantity(minQuantity),
 	)
 
 	// Create entity in the database
-	db, ok := middleware.GetDbFromContext(r.Context())
-	if !ok {
-		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
-		return
-	}
 	if err := db.CreateEntity(r.Context(), entity); err != nil {
+		if errors.Is(err, database.ErrCodeInUse) {
+			http.Error(w, "Code already assigned to another entity", http.StatusConflict)
+			return
+		}
+		if errors.Is(err, database.ErrEntityExists) {
+			http.Error(w, "Entity id already in use", http.StatusConflict)
+			return
+		}
+		if errors.Is(err, database.ErrParentNotFound) {
+			http.Error(w, "Parent entity not found", http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, database.ErrTagCreationDenied) {
+			middleware.WriteForbidden(w, models.RoleEditor, "", "")
+			return
+		}
+		if errors.Is(err, models.ErrInvalidAttribute) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		log.Println(err)
 		http.Error(w, "Unable to insert entity", http.StatusInternalServerError)
 		return
 	}
 
 	// Return created entity
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(entity); err != nil {
-		http.Error(w, "Unable to create response", http.StatusInternalServerError)
-		return
-	}
+	writeJson(w, http.StatusOK, entity)
 }
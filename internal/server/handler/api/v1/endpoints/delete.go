@@ -0,0 +1,49 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/models"
+	"Backend/internal/server/middleware"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// Delete
+// soft deletes the entity, ?cascade=true deletes the entity together with all of its descendants.
+func Delete(w http.ResponseWriter, r *http.Request) {
+
+	id := r.PathValue("id")
+
+	cascade := false
+	if raw := r.URL.Query().Get("cascade"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			http.Error(w, "Invalid cascade parameter", http.StatusBadRequest)
+			return
+		}
+		cascade = parsed
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	if err := db.DeleteEntity(r.Context(), id, cascade); err != nil {
+		switch {
+		case errors.Is(err, database.ErrEntityNotFound):
+			http.Error(w, "Entity not found", http.StatusNotFound)
+		case errors.Is(err, models.ErrEntityHasChildren):
+			http.Error(w, "Entity has child entities, delete them first or use cascade=true", http.StatusConflict)
+		default:
+			log.Println(err)
+			http.Error(w, "Unable to delete entity", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,174 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/models"
+	"Backend/internal/server/middleware"
+	"Backend/internal/thumbnail"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// FormMaxMemory is the part of an entity form kept in memory, the rest goes to temporary files
+const FormMaxMemory = 10 << 20 // 10 MB
+
+// parseEntityForm
+// parses the request as POST form-data, writing the error response itself on failure.
+func parseEntityForm(w http.ResponseWriter, r *http.Request) bool {
+	if err := r.ParseMultipartForm(FormMaxMemory); err != nil {
+		switch {
+		case errors.Is(err, http.ErrNotMultipart) || errors.Is(err, http.ErrMissingBoundary):
+			http.Error(w, "Form data not present or not multipart", http.StatusBadRequest)
+		case err.Error() == "multipart: NextPart: EOF":
+			http.Error(w, "No form data present", http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to parse form, 10MB limit exceeded", http.StatusRequestEntityTooLarge)
+		}
+		return false
+	}
+	return true
+}
+
+// formValue
+// returns the value of a form field and whether the field was sent at all,
+// so that an omitted field can be told apart from an empty one.
+func formValue(r *http.Request, key string) (string, bool) {
+	if r.MultipartForm == nil {
+		return "", false
+	}
+	values, ok := r.MultipartForm.Value[key]
+	if !ok || len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+// formAttributes
+// reads the "attributes" field, a JSON object of attribute values.
+func formAttributes(r *http.Request) (models.Attributes, bool, error) {
+	raw, ok := formValue(r, "attributes")
+	if !ok || raw == "" {
+		return nil, false, nil
+	}
+
+	var attrs models.Attributes
+	if err := json.Unmarshal([]byte(raw), &attrs); err != nil {
+		return nil, true, errors.New("attributes must be a JSON object")
+	}
+
+	return attrs, true, nil
+}
+
+// checkFormAttributes
+// validates the attributes against the schema of the workspace, so that a form is rejected
+// before its images are uploaded. The database checks them again when storing the entity.
+// Null values remove a key on update and are not checked.
+func checkFormAttributes(r *http.Request, db database.Repository, attrs models.Attributes) error {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	schema, err := db.QueryAttributeSchema(r.Context())
+	if err != nil {
+		return err
+	}
+	defs := make(map[string]*models.AttributeDefinition, len(schema))
+	for _, d := range schema {
+		defs[d.Key] = d
+	}
+
+	set := make(models.Attributes, len(attrs))
+	for key, value := range attrs {
+		if value != nil {
+			set[key] = value
+		}
+	}
+
+	_, err = models.NormalizeAttributes(defs, set)
+	return err
+}
+
+// parseQuantity
+// reads a non-negative whole number, as used for quantity and min_quantity.
+func parseQuantity(raw string) (int64, error) {
+	quantity, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || quantity < 0 {
+		return 0, errors.New("quantities must be whole numbers of at least 0")
+	}
+	return quantity, nil
+}
+
+// uploadFormImages
+// generates and uploads thumbnails for every file in the "images" field, returning their urls.
+// Images are stored in the folder of the workspace.
+func uploadFormImages(r *http.Request, entityId string) ([]string, error) {
+
+	images := r.MultipartForm.File["images"]
+	thumbnails := make([]string, 0)
+
+	workspaceId, ok := database.WorkspaceFromContext(r.Context())
+	if !ok {
+		return nil, database.ErrNoWorkspace
+	}
+
+	// Get Minio Object
+	objStore, ok := middleware.GetObjStoreFromContext(r.Context())
+	if !ok {
+		return nil, errors.New("unable to load ObjectStore instance")
+	}
+
+	var thErrFlag error
+	mut := sync.Mutex{}
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(images))
+	for _, img := range images {
+		go func(img *multipart.FileHeader, _id string) {
+			defer wg.Done()
+
+			imgBody, err := img.Open()
+			if err != nil {
+				log.Printf("%v", err)
+				mut.Lock()
+				thErrFlag = err
+				mut.Unlock()
+				return
+			}
+			defer imgBody.Close()
+
+			t, err := thumbnail.NewThumbnailsFromMultipart(imgBody, _id, thumbnail.WithThumbnailPrefix(workspaceId))
+			if err != nil {
+				log.Printf("%v", err)
+				mut.Lock()
+				thErrFlag = err
+				mut.Unlock()
+				return
+			}
+
+			if err := objStore.UploadThumbnail(r.Context(), t); err != nil {
+				log.Printf("%v", err)
+				mut.Lock()
+				thErrFlag = err
+				mut.Unlock()
+				return
+			}
+
+			mut.Lock()
+			thumbnails = append(thumbnails, fmt.Sprintf("/image/v1/%s", t.GetImageBaseNameWithExt()))
+			mut.Unlock()
+		}(img, entityId)
+	}
+	wg.Wait()
+
+	if thErrFlag != nil {
+		return nil, thErrFlag
+	}
+
+	return thumbnails, nil
+}
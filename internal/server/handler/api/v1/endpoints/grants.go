@@ -0,0 +1,94 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/server/middleware"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+func handleGrantError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, database.ErrEntityNotFound):
+		http.Error(w, "Entity not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrUserNotFound):
+		http.Error(w, "User is not a member of the workspace", http.StatusNotFound)
+	case errors.Is(err, database.ErrGrantNotFound):
+		http.Error(w, "Grant not found", http.StatusNotFound)
+	default:
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+	}
+}
+
+// ListGrants
+// lists the grants that apply to the entity, including the ones inherited from its ancestors.
+func ListGrants(w http.ResponseWriter, r *http.Request) {
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	grants, err := db.QueryGrants(r.Context(), r.PathValue("id"))
+	if err != nil {
+		handleGrantError(w, err)
+		return
+	}
+
+	writeJson(w, http.StatusOK, grants)
+}
+
+// PutGrant
+// expects a JSON body {"role": "editor"}, giving the member that role on the entity and
+// everything below it.
+func PutGrant(w http.ResponseWriter, r *http.Request) {
+
+	var req roleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Request body is not valid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Role == "" {
+		http.Error(w, "Role is required", http.StatusBadRequest)
+		return
+	}
+
+	role, ok := parseRole(w, req.Role, "")
+	if !ok {
+		return
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	grant, err := db.GrantRole(r.Context(), r.PathValue("id"), r.PathValue("userId"), role)
+	if err != nil {
+		handleGrantError(w, err)
+		return
+	}
+
+	writeJson(w, http.StatusOK, grant)
+}
+
+func RevokeGrant(w http.ResponseWriter, r *http.Request) {
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	if err := db.RevokeGrant(r.Context(), r.PathValue("id"), r.PathValue("userId")); err != nil {
+		handleGrantError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
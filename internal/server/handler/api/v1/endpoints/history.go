@@ -0,0 +1,100 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/server/middleware"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 500
+)
+
+// History
+// returns the changes of an entity newest first. ?limit= caps the number of events and
+// ?before=<RFC 3339 time> continues from the created_at of the last event seen.
+func History(w http.ResponseWriter, r *http.Request) {
+
+	id := r.PathValue("id")
+	queryParams := r.URL.Query()
+
+	limit := defaultHistoryLimit
+	if raw := queryParams.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+		limit = min(parsed, maxHistoryLimit)
+	}
+
+	var before time.Time
+	if raw := queryParams.Get("before"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			http.Error(w, "before must be an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+		before = parsed
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	events, err := db.QueryHistory(r.Context(), id, limit, before)
+	if err != nil {
+		if errors.Is(err, database.ErrEntityNotFound) {
+			http.Error(w, "Entity not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, http.StatusOK, events)
+}
+
+// Location
+// answers where an entity was at ?at=<RFC 3339 time>, now when omitted.
+func Location(w http.ResponseWriter, r *http.Request) {
+
+	id := r.PathValue("id")
+
+	at := time.Now()
+	if raw := r.URL.Query().Get("at"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			http.Error(w, "at must be an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+		at = parsed
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	location, err := db.QueryLocationAt(r.Context(), id, at)
+	if err != nil {
+		if errors.Is(err, database.ErrNoHistory) {
+			http.Error(w, "Entity did not exist at that time", http.StatusNotFound)
+			return
+		}
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, http.StatusOK, location)
+}
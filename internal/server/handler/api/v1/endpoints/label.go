@@ -0,0 +1,171 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/env"
+	"Backend/internal/label"
+	"Backend/internal/models"
+	"Backend/internal/server/middleware"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+func LabelPng(w http.ResponseWriter, r *http.Request) {
+	serveLabel(w, r, "png", "image/png")
+}
+
+func LabelSvg(w http.ResponseWriter, r *http.Request) {
+	serveLabel(w, r, "svg", "image/svg+xml")
+}
+
+// labelUrl
+// returns the absolute url a label for the code points at, based on PUBLIC_BASE_URL.
+// It is opened by phones scanning the label, see OpenLabel.
+func labelUrl(code string) string {
+	base := strings.TrimRight(env.GetStaticEnv().PublicBaseUrl, "/")
+	return fmt.Sprintf("%s/r/%s", base, code)
+}
+
+// labelsAvailable
+// answers 503 and returns false while labels cannot be made, because PUBLIC_BASE_URL or
+// LABEL_TARGET_URL is not configured.
+func labelsAvailable(w http.ResponseWriter) bool {
+	e := env.GetStaticEnv()
+	if err := errors.Join(e.CheckPublicBaseUrl(), e.CheckLabelTargetUrl()); err != nil {
+		http.Error(w, fmt.Sprintf("Labels are unavailable, %v", err), http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+// OpenLabel
+// is what a scanned label opens in the browser of a phone. It redirects to the page of
+// LABEL_TARGET_URL for the code, which resolves it through GET /api/v1/resolve/{code} once
+// the user is logged in. It needs no authentication, the redirect reveals nothing about the entity.
+func OpenLabel(w http.ResponseWriter, r *http.Request) {
+
+	if !labelsAvailable(w) {
+		return
+	}
+
+	code, err := models.NormalizeCode(r.PathValue("code"))
+	if err != nil {
+		http.Error(w, "Invalid code", http.StatusBadRequest)
+		return
+	}
+
+	target := strings.ReplaceAll(env.GetStaticEnv().LabelTargetUrl, "{code}", url.PathEscape(code))
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// serveLabel
+// renders the QR label of the entity, ?size= sets its width in pixels. Rendered labels are
+// cached in the object store under a key that changes with anything printed on them.
+func serveLabel(w http.ResponseWriter, r *http.Request, ext string, contentType string) {
+
+	if !labelsAvailable(w) {
+		return
+	}
+
+	id := r.PathValue("id")
+
+	size := label.DefaultSize
+	if raw := r.URL.Query().Get("size"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < label.MinSize || parsed > label.MaxSize {
+			http.Error(w, label.ErrInvalidSize.Error(), http.StatusBadRequest)
+			return
+		}
+		size = parsed
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	objStore, ok := middleware.GetObjStoreFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load ObjectStore instance", http.StatusInternalServerError)
+		return
+	}
+
+	entity, err := db.QueryById(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrEntityNotFound) {
+			http.Error(w, "Entity not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		return
+	}
+	if entity.Code == nil {
+		http.Error(w, "Entity has no code assigned", http.StatusInternalServerError)
+		return
+	}
+
+	ancestors, err := db.QueryAncestors(r.Context(), id)
+	if err != nil {
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		return
+	}
+
+	path := make([]string, 0, len(ancestors))
+	for _, a := range ancestors {
+		path = append(path, a.Name)
+	}
+
+	l := label.NewLabel(
+		labelUrl(*entity.Code),
+		label.LabelWithName(entity.Name),
+		label.LabelWithPath(path),
+		label.LabelWithSize(size),
+	)
+	key := fmt.Sprintf("%s/%s", entity.WorkspaceId, l.CacheKey(entity.Id, ext))
+
+	var img []byte
+	if cached, err := objStore.RetrieveImage(r.Context(), key); err == nil {
+		img, err = io.ReadAll(cached)
+		_ = cached.Close()
+		if err != nil {
+			img = nil
+		}
+	}
+
+	if img == nil {
+		switch ext {
+		case "svg":
+			img, err = l.SVG()
+		default:
+			img, err = l.PNG()
+		}
+		if err != nil {
+			log.Printf("[Error] Unable to render label: %v", err)
+			http.Error(w, "Unable to render label", http.StatusInternalServerError)
+			return
+		}
+
+		// A failed upload only costs a re-render next time
+		if err := objStore.UploadObject(r.Context(), key, img, contentType); err != nil {
+			log.Printf("[Error] Unable to cache label: %v", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(img)))
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write(img); err != nil {
+		log.Printf("[Error] Unable write label")
+	}
+}
@@ -0,0 +1,118 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/label"
+	"Backend/internal/server/middleware"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const maxSheetLabels = 300
+
+type labelSheetRequest struct {
+	Ids    []string `json:"ids"`
+	Layout string   `json:"layout"`
+	Skip   int      `json:"skip"` // Positions already used on the first sheet
+}
+
+// LabelSheet
+// returns a PDF of QR labels for the entities, laid out for a sheet of sticker labels.
+// Expects a JSON body {"ids": [...], "layout": "avery5160", "skip": 0}, labels follow the order of ids.
+func LabelSheet(w http.ResponseWriter, r *http.Request) {
+
+	if !labelsAvailable(w) {
+		return
+	}
+
+	var req labelSheetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Request body is not valid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Ids) == 0 || len(req.Ids) > maxSheetLabels {
+		http.Error(w, fmt.Sprintf("Between 1 and %v ids are required", maxSheetLabels), http.StatusBadRequest)
+		return
+	}
+
+	if req.Layout == "" {
+		req.Layout = "avery5160"
+	}
+	layout, ok := label.SheetLayouts[req.Layout]
+	if !ok {
+		names := make([]string, 0, len(label.SheetLayouts))
+		for name := range label.SheetLayouts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		http.Error(w, fmt.Sprintf("Unknown layout, use one of %s", strings.Join(names, ", ")), http.StatusBadRequest)
+		return
+	}
+
+	if req.Skip < 0 || req.Skip >= layout.PerPage() {
+		http.Error(w, fmt.Sprintf("skip must be between 0 and %v", layout.PerPage()-1), http.StatusBadRequest)
+		return
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	page, err := db.QueryMultipleById(r.Context(), database.PageRequest{}, req.Ids...)
+	if err != nil {
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		return
+	}
+
+	items := make(map[string]*label.SheetItem, len(page.Entities))
+	for _, e := range page.Entities {
+		if e.Code == nil {
+			continue
+		}
+		items[e.Id] = &label.SheetItem{
+			Url:  labelUrl(*e.Code),
+			Name: e.Name,
+			Code: *e.Code,
+		}
+	}
+
+	ordered := make([]*label.SheetItem, 0, len(req.Ids))
+	missing := make([]string, 0)
+	for _, id := range req.Ids {
+		item, ok := items[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		ordered = append(ordered, item)
+	}
+	if len(missing) > 0 {
+		http.Error(w, fmt.Sprintf("Entities not found: %s", strings.Join(missing, ", ")), http.StatusNotFound)
+		return
+	}
+
+	pdf, err := label.Sheet(layout, ordered, req.Skip)
+	if err != nil {
+		log.Printf("[Error] Unable to render label sheet: %v", err)
+		http.Error(w, "Unable to render label sheet", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Length", strconv.Itoa(len(pdf)))
+	w.Header().Set("Content-Disposition", `inline; filename="labels.pdf"`)
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write(pdf); err != nil {
+		log.Printf("[Error] Unable write label sheet")
+	}
+}
@@ -0,0 +1,54 @@
+package endpoints
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLabelSheetWithUnknownIdIsNotFound(t *testing.T) {
+	router, _ := newLabelRouter(t, "https://tt.example.com")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/labels/sheet", strings.NewReader(`{"ids": ["drill", "saw"]}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), "saw") {
+		t.Errorf("expected the response to name the unknown id, got %q", rec.Body)
+	}
+}
+
+func TestLabelSheetSizeLimit(t *testing.T) {
+	router, _ := newLabelRouter(t, "https://tt.example.com")
+
+	for _, count := range []int{0, maxSheetLabels + 1} {
+		ids := make([]string, count)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("entity-%d", i)
+		}
+		body, err := json.Marshal(labelSheetRequest{Ids: ids})
+		if err != nil {
+			t.Fatalf("unable to encode request: %v", err)
+		}
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/labels/sheet", bytes.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %d ids, got %d", count, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/labels/sheet", strings.NewReader(`{"ids": ["drill"]}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a single id, got %d: %s", rec.Code, rec.Body)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/pdf" {
+		t.Errorf("expected a PDF, got %q", contentType)
+	}
+}
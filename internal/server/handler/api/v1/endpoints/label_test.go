@@ -0,0 +1,131 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/env"
+	"Backend/internal/label"
+	"Backend/internal/models"
+	"Backend/internal/objectstore"
+	"Backend/internal/server/middleware"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newLabelRouter serves the label routes for a new workspace holding a drill on a shelf
+func newLabelRouter(t *testing.T, publicBaseUrl string) (http.Handler, *models.Entity) {
+	t.Helper()
+
+	e := env.GetStaticEnv()
+	previous := *e
+	e.PublicBaseUrl = publicBaseUrl
+	t.Cleanup(func() { *e = previous })
+
+	db, err := database.CreateGormSqliteAdapter(filepath.Join(t.TempDir(), "tt.db"))
+	if err != nil {
+		t.Fatalf("unable to create adapter: %v", err)
+	}
+	ctx := context.Background()
+	if err := db.Migrate(ctx); err != nil {
+		t.Fatalf("unable to migrate: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Disconnect(ctx) })
+
+	user, err := models.NewUser("alice", "correct horse")
+	if err != nil {
+		t.Fatalf("unable to create user: %v", err)
+	}
+	if err := db.CreateUser(ctx, user); err != nil {
+		t.Fatalf("unable to store user: %v", err)
+	}
+	workspace, err := db.CreateWorkspace(ctx, "Home", user.Id)
+	if err != nil {
+		t.Fatalf("unable to create workspace: %v", err)
+	}
+	ctx = database.WithWorkspace(ctx, workspace.Id)
+
+	drill := models.NewEntity(
+		models.EntityWithId("drill"),
+		models.EntityWithName("Drill"),
+		models.EntityWithParentId("shelf"),
+		models.EntityWithCode("DR7K42"),
+	)
+	for _, entity := range []*models.Entity{
+		models.NewEntity(models.EntityWithId("shelf"), models.EntityWithName("Shelf")),
+		drill,
+	} {
+		if err := db.CreateEntity(ctx, entity); err != nil {
+			t.Fatalf("unable to create %s: %v", entity.Id, err)
+		}
+	}
+
+	router := http.NewServeMux()
+	router.HandleFunc("GET /entities/{id}/label.png", LabelPng)
+	router.HandleFunc("POST /labels/sheet", LabelSheet)
+	router.HandleFunc("GET /r/{code}", OpenLabel)
+
+	return middleware.Apply(
+		router,
+		func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, r.WithContext(database.WithWorkspace(r.Context(), workspace.Id)))
+			})
+		},
+		middleware.ApplyAttachObjStore(objectstore.NewMemoryAdapter()),
+		middleware.ApplyAttachDb(db),
+	), drill
+}
+
+func TestLabelEncodesTheRedirectOfThePublicBaseUrl(t *testing.T) {
+	router, drill := newLabelRouter(t, "https://tt.example.com/")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/entities/drill/label.png", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	expected, err := label.NewLabel(
+		"https://tt.example.com/r/"+*drill.Code,
+		label.LabelWithName("Drill"),
+		label.LabelWithPath([]string{"Shelf"}),
+	).PNG()
+	if err != nil {
+		t.Fatalf("unable to render the expected label: %v", err)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), expected) {
+		t.Error("expected the label to encode PUBLIC_BASE_URL/r/{code}")
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/r/"+*drill.Code, nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected 302 for a scanned label, got %d", rec.Code)
+	}
+	if location := rec.Header().Get("Location"); location != "/?code="+*drill.Code {
+		t.Errorf("expected a redirect to the page of the code, got %q", location)
+	}
+}
+
+func TestLabelsAreUnavailableWithoutPublicBaseUrl(t *testing.T) {
+	router, drill := newLabelRouter(t, "")
+
+	for _, r := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/entities/drill/label.png", nil),
+		httptest.NewRequest(http.MethodPost, "/labels/sheet", strings.NewReader(`{"ids": ["drill"]}`)),
+		httptest.NewRequest(http.MethodGet, "/r/"+*drill.Code, nil),
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, r)
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected 503 for %s %s, got %d", r.Method, r.URL.Path, rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), "PUBLIC_BASE_URL") {
+			t.Errorf("expected the response of %s %s to name PUBLIC_BASE_URL, got %q", r.Method, r.URL.Path, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/server/middleware"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const maxBorrowerLength = 200
+
+type checkOutRequest struct {
+	Borrower string     `json:"borrower"`
+	DueAt    *time.Time `json:"due_at"`
+	Note     string     `json:"note"`
+}
+
+// CheckOut
+// lends an entity, expects a JSON body {"borrower": "Sam", "due_at": "<RFC 3339 time>", "note": ""}
+// where only the borrower is required. The loan lists the contents that leave with the entity.
+func CheckOut(w http.ResponseWriter, r *http.Request) {
+
+	id := r.PathValue("id")
+
+	var req checkOutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Request body is not valid JSON, due_at must be an RFC 3339 time", http.StatusBadRequest)
+		return
+	}
+
+	borrower := strings.TrimSpace(req.Borrower)
+	if borrower == "" || len(borrower) > maxBorrowerLength {
+		http.Error(w, "borrower is required and at most 200 characters long", http.StatusBadRequest)
+		return
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	loan, err := db.CheckOut(r.Context(), id, borrower, req.DueAt, req.Note)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrEntityNotFound):
+			http.Error(w, "Entity not found", http.StatusNotFound)
+		case errors.Is(err, database.ErrAlreadyCheckedOut):
+			// Names the lent entity, which may be a container or a descendant of the requested one
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			log.Println(err)
+			http.Error(w, "Unable to check out entity", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJson(w, http.StatusCreated, loan)
+}
+
+// CheckIn
+// ends the active loan of an entity.
+func CheckIn(w http.ResponseWriter, r *http.Request) {
+
+	id := r.PathValue("id")
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	loan, err := db.CheckIn(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotCheckedOut) {
+			http.Error(w, "Entity is not checked out", http.StatusConflict)
+			return
+		}
+		log.Println(err)
+		http.Error(w, "Unable to check in entity", http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, http.StatusOK, loan)
+}
+
+// Loans
+// lists the active loans, soonest due first.
+func Loans(w http.ResponseWriter, r *http.Request) {
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	loans, err := db.QueryActiveLoans(r.Context())
+	if err != nil {
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, http.StatusOK, loans)
+}
+
+// OverdueLoans
+// lists the active loans past their due date.
+func OverdueLoans(w http.ResponseWriter, r *http.Request) {
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	loans, err := db.QueryOverdueLoans(r.Context(), time.Now())
+	if err != nil {
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, http.StatusOK, loans)
+}
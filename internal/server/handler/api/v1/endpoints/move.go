@@ -0,0 +1,65 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/server/middleware"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+type moveRequest struct {
+	// ParentId is kept raw so that a missing field can be told apart from an explicit null
+	ParentId json.RawMessage `json:"parent_id"`
+}
+
+// Move
+// re-parents the entity, expects a JSON body {"parent_id": "<id>"} or {"parent_id": null} for top level.
+func Move(w http.ResponseWriter, r *http.Request) {
+
+	id := r.PathValue("id")
+
+	var req moveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Request body is not valid JSON", http.StatusBadRequest)
+		return
+	}
+	if len(req.ParentId) == 0 {
+		http.Error(w, "parent_id is required, use null to move to the top level", http.StatusBadRequest)
+		return
+	}
+
+	var parentId *string
+	if err := json.Unmarshal(req.ParentId, &parentId); err != nil {
+		http.Error(w, "parent_id must be a string or null", http.StatusBadRequest)
+		return
+	}
+	if parentId != nil && *parentId == "" {
+		parentId = nil
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	entity, err := db.MoveEntity(r.Context(), id, parentId)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrEntityNotFound):
+			http.Error(w, "Entity not found", http.StatusNotFound)
+		case errors.Is(err, database.ErrParentNotFound):
+			http.Error(w, "Parent entity not found", http.StatusBadRequest)
+		case errors.Is(err, database.ErrMoveCycle):
+			http.Error(w, "Entity cannot be moved into itself or its descendants", http.StatusConflict)
+		default:
+			log.Println(err)
+			http.Error(w, "Unable to move entity", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJson(w, http.StatusOK, entity)
+}
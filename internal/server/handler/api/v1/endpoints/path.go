@@ -0,0 +1,35 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/server/middleware"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// Path
+// returns the ancestors of the entity from the top level down, e.g. Garage → Shelf 2 → Red Box.
+func Path(w http.ResponseWriter, r *http.Request) {
+
+	id := r.PathValue("id")
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	ancestors, err := db.QueryAncestors(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrEntityNotFound) {
+			http.Error(w, "Entity not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, http.StatusOK, ancestors)
+}
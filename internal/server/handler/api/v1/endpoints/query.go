@@ -1,19 +1,104 @@
 package endpoints
 
 import (
+	"Backend/internal/database"
 	"Backend/internal/models"
 	"Backend/internal/server/middleware"
-	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 )
 
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// parsePageRequest
+// reads ?limit=, ?cursor=, ?sort=name|created_at|updated_at and ?order=asc|desc.
+func parsePageRequest(queryParams url.Values) (database.PageRequest, error) {
+	page := database.PageRequest{
+		Limit:  defaultPageLimit,
+		Cursor: queryParams.Get("cursor"),
+		Sort:   database.SortByCreatedAt,
+	}
+
+	if raw := queryParams.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return page, errors.New("invalid limit parameter")
+		}
+		page.Limit = min(limit, maxPageLimit)
+	}
+
+	if raw := queryParams.Get("sort"); raw != "" {
+		sort, err := database.SortFieldFromString(raw)
+		if err != nil {
+			return page, err
+		}
+		page.Sort = sort
+	}
+
+	switch queryParams.Get("order") {
+	case "", "asc":
+		page.Desc = false
+	case "desc":
+		page.Desc = true
+	default:
+		return page, errors.New("invalid order parameter")
+	}
+
+	return page, nil
+}
+
+// parseEntityFilter
+// reads ?tag= (repeatable) and ?tag_match=or|and, entities need any of the tags by default.
+// ?attr= (repeatable) filters on attribute values, e.g. attr=price>100 or attr=color=red.
+func parseEntityFilter(queryParams url.Values) (database.EntityFilter, error) {
+	filter := database.EntityFilter{
+		Tags: models.NormalizeTagNames(queryParams["tag"]),
+	}
+
+	switch queryParams.Get("tag_match") {
+	case "", "or":
+		filter.MatchAllTags = false
+	case "and":
+		filter.MatchAllTags = true
+	default:
+		return filter, errors.New("invalid tag_match parameter")
+	}
+
+	for _, raw := range queryParams["attr"] {
+		cond, err := database.ParseAttributeCondition(raw)
+		if err != nil {
+			return filter, err
+		}
+		filter.Attributes = append(filter.Attributes, cond)
+	}
+
+	return filter, nil
+}
+
 func Query(w http.ResponseWriter, r *http.Request) {
 
 	// Get ids params
 	queryParams := r.URL.Query()
 	ids := queryParams["id"]
 
+	page, err := parsePageRequest(queryParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseEntityFilter(queryParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	db, ok := middleware.GetDbFromContext(r.Context())
 	if !ok {
 		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
@@ -21,23 +106,38 @@ func Query(w http.ResponseWriter, r *http.Request) {
 	}
 
 	handleDbError := func(err error) {
-		log.Printf("[Error] Unable to query DB, %v", err)
-		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, database.ErrEntityNotFound):
+			http.Error(w, "Entity not found", http.StatusNotFound)
+		case errors.Is(err, database.ErrInvalidCursor):
+			http.Error(w, "Invalid cursor parameter", http.StatusBadRequest)
+		case errors.Is(err, database.ErrInvalidAttributeCondition):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Printf("[Error] Unable to query DB, %v", err)
+			http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		}
 	}
 
-	handleRes := func(entities []*models.Entity) {
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(entities); err != nil {
-			http.Error(w, "Unable to create response", http.StatusInternalServerError)
-			return
-		}
+	handleRes := func(page *database.Page) {
+		writeJson(w, http.StatusOK, page)
 	}
 
 	switch {
+	case !filter.IsEmpty():
+		filter.Ids = ids
+		entities, err := db.QueryFiltered(r.Context(), page, filter)
+		if err != nil {
+			handleDbError(err)
+			return
+		}
+		handleRes(entities)
+		return
 	case len(ids) == 0:
-		entities, err := db.QueryTopLevel(r.Context())
+		entities, err := db.QueryTopLevel(r.Context(), page)
 		if err != nil {
 			handleDbError(err)
+			return
 		}
 		handleRes(entities)
 		return
@@ -45,13 +145,15 @@ func Query(w http.ResponseWriter, r *http.Request) {
 		entity, err := db.QueryById(r.Context(), ids[0])
 		if err != nil {
 			handleDbError(err)
+			return
 		}
-		handleRes([]*models.Entity{entity})
+		handleRes(&database.Page{Entities: []*models.Entity{entity}})
 		return
 	case len(ids) > 1:
-		entities, err := db.QueryMultipleById(r.Context(), ids...)
+		entities, err := db.QueryMultipleById(r.Context(), page, ids...)
 		if err != nil {
 			handleDbError(err)
+			return
 		}
 		handleRes(entities)
 		return
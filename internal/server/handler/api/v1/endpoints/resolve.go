@@ -0,0 +1,40 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/models"
+	"Backend/internal/server/middleware"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// Resolve
+// returns the entity labelled with the code, so that a scanner can jump straight to it.
+func Resolve(w http.ResponseWriter, r *http.Request) {
+
+	code, err := models.NormalizeCode(r.PathValue("code"))
+	if err != nil {
+		http.Error(w, "Invalid code", http.StatusBadRequest)
+		return
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	entity, err := db.QueryByCode(r.Context(), code)
+	if err != nil {
+		if errors.Is(err, database.ErrEntityNotFound) {
+			http.Error(w, "No entity with this code", http.StatusNotFound)
+			return
+		}
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, http.StatusOK, entity)
+}
@@ -0,0 +1,26 @@
+package endpoints
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// writeJson
+// encodes the body before anything is written, so that a body that cannot be encoded
+// still gets a 500 instead of a truncated response with the intended status.
+func writeJson(w http.ResponseWriter, status int, body any) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		log.Printf("[Error] Unable to create response, %v", err)
+		http.Error(w, "Unable to create response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Printf("[Error] Unable to write response, %v", err)
+	}
+}
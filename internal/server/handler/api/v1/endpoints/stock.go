@@ -0,0 +1,81 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/server/middleware"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+const maxReasonLength = 500
+
+type adjustRequest struct {
+	Delta  int64  `json:"delta"`
+	Reason string `json:"reason"`
+}
+
+// Adjust
+// changes the quantity of an entity, expects a JSON body {"delta": -2, "reason": "used for the remote"}.
+// Adjustments that would take the quantity below zero are refused with 409.
+func Adjust(w http.ResponseWriter, r *http.Request) {
+
+	id := r.PathValue("id")
+
+	var req adjustRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Request body is not valid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Delta == 0 {
+		http.Error(w, "delta must be a non-zero whole number", http.StatusBadRequest)
+		return
+	}
+	if len(req.Reason) > maxReasonLength {
+		http.Error(w, "reason is too long", http.StatusBadRequest)
+		return
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	entity, err := db.AdjustQuantity(r.Context(), id, req.Delta, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrEntityNotFound):
+			http.Error(w, "Entity not found", http.StatusNotFound)
+		case errors.Is(err, database.ErrInsufficientQuantity):
+			http.Error(w, "Not enough stock for this adjustment", http.StatusConflict)
+		default:
+			log.Println(err)
+			http.Error(w, "Unable to adjust quantity", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJson(w, http.StatusOK, entity)
+}
+
+// LowStock
+// lists the entities whose quantity is at or below their min_quantity.
+func LowStock(w http.ResponseWriter, r *http.Request) {
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	entities, err := db.QueryLowStock(r.Context())
+	if err != nil {
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, http.StatusOK, entities)
+}
@@ -0,0 +1,172 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/models"
+	"Backend/internal/server/middleware"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type tagRequest struct {
+	Name string `json:"name"`
+}
+
+type attachTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+func handleTagError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, database.ErrEntityNotFound):
+		http.Error(w, "Entity not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrTagNotFound):
+		http.Error(w, "Tag not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrTagExists):
+		http.Error(w, "Tag name already in use", http.StatusConflict)
+	case errors.Is(err, database.ErrTagCreationDenied):
+		middleware.WriteForbidden(w, models.RoleEditor, "", "")
+	default:
+		log.Println(err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+	}
+}
+
+// decodeTagRequest
+// reads a {"name": "..."} body, writing the error response itself on failure.
+func decodeTagRequest(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req tagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Request body is not valid JSON", http.StatusBadRequest)
+		return "", false
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		http.Error(w, "Tag name is required", http.StatusBadRequest)
+		return "", false
+	}
+
+	return name, true
+}
+
+func ListTags(w http.ResponseWriter, r *http.Request) {
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	tags, err := db.QueryTags(r.Context())
+	if err != nil {
+		handleTagError(w, err)
+		return
+	}
+
+	writeJson(w, http.StatusOK, tags)
+}
+
+// CreateTag
+// expects a JSON body {"name": "..."}.
+func CreateTag(w http.ResponseWriter, r *http.Request) {
+
+	name, ok := decodeTagRequest(w, r)
+	if !ok {
+		return
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	tag, err := db.CreateTag(r.Context(), name)
+	if err != nil {
+		handleTagError(w, err)
+		return
+	}
+
+	writeJson(w, http.StatusCreated, tag)
+}
+
+// RenameTag
+// expects a JSON body {"name": "..."}.
+func RenameTag(w http.ResponseWriter, r *http.Request) {
+
+	id := r.PathValue("id")
+
+	name, ok := decodeTagRequest(w, r)
+	if !ok {
+		return
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	tag, err := db.RenameTag(r.Context(), id, name)
+	if err != nil {
+		handleTagError(w, err)
+		return
+	}
+
+	writeJson(w, http.StatusOK, tag)
+}
+
+// AttachTags
+// expects a JSON body {"tags": ["...", ...]} of tag names, missing tags are created
+// when the user is an editor of the workspace.
+func AttachTags(w http.ResponseWriter, r *http.Request) {
+
+	id := r.PathValue("id")
+
+	var req attachTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Request body is not valid JSON", http.StatusBadRequest)
+		return
+	}
+
+	names := models.NormalizeTagNames(req.Tags)
+	if len(names) == 0 {
+		http.Error(w, "At least one tag is required", http.StatusBadRequest)
+		return
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	entity, err := db.AttachTags(r.Context(), id, names...)
+	if err != nil {
+		handleTagError(w, err)
+		return
+	}
+
+	writeJson(w, http.StatusOK, entity)
+}
+
+func DetachTag(w http.ResponseWriter, r *http.Request) {
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	entity, err := db.DetachTag(r.Context(), r.PathValue("id"), r.PathValue("tagId"))
+	if err != nil {
+		handleTagError(w, err)
+		return
+	}
+
+	writeJson(w, http.StatusOK, entity)
+}
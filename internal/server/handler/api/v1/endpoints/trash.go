@@ -0,0 +1,121 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/env"
+	"Backend/internal/models"
+	"Backend/internal/server/middleware"
+	"Backend/internal/trash"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type trashItem struct {
+	*models.Entity
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+type purgeResponse struct {
+	Purged []string `json:"purged"`
+}
+
+// Trash
+// lists the soft-deleted entities.
+func Trash(w http.ResponseWriter, r *http.Request) {
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	entities, err := db.QueryDeleted(r.Context())
+	if err != nil {
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		return
+	}
+
+	items := make([]*trashItem, 0, len(entities))
+	for _, e := range entities {
+		items = append(items, &trashItem{
+			Entity:    e,
+			DeletedAt: e.DeletedAt.Time,
+		})
+	}
+
+	writeJson(w, http.StatusOK, items)
+}
+
+// Restore
+// brings an entity back from the trash, ?cascade=true restores the descendants deleted together with it.
+func Restore(w http.ResponseWriter, r *http.Request) {
+
+	id := r.PathValue("id")
+
+	cascade := false
+	if raw := r.URL.Query().Get("cascade"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			http.Error(w, "Invalid cascade parameter", http.StatusBadRequest)
+			return
+		}
+		cascade = parsed
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	entity, err := db.RestoreEntity(r.Context(), id, cascade)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrEntityNotFound):
+			http.Error(w, "Entity not found in trash", http.StatusNotFound)
+		case errors.Is(err, database.ErrParentDeleted):
+			http.Error(w, "Parent entity is deleted, restore it first", http.StatusConflict)
+		default:
+			log.Println(err)
+			http.Error(w, "Unable to restore entity", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJson(w, http.StatusOK, entity)
+}
+
+// Purge
+// permanently removes the entities that have outlived the trash retention.
+func Purge(w http.ResponseWriter, r *http.Request) {
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	objStore, ok := middleware.GetObjStoreFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load ObjectStore instance", http.StatusInternalServerError)
+		return
+	}
+
+	purged, err := trash.Purge(r.Context(), db, objStore, env.GetStaticEnv().TrashRetention)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Unable to purge trash", http.StatusInternalServerError)
+		return
+	}
+
+	res := &purgeResponse{Purged: make([]string, 0, len(purged))}
+	for _, e := range purged {
+		res.Purged = append(res.Purged, e.Id)
+	}
+
+	writeJson(w, http.StatusOK, res)
+}
@@ -0,0 +1,62 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/server/middleware"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+const (
+	maxTreeDepth = 32   // deepest level returned, also used when no depth is given
+	maxTreeNodes = 5000 // entities returned before the tree is truncated
+)
+
+// Tree
+// returns the entity with its descendants nested under children, ?depth=N limits the levels returned
+// and may be at most maxTreeDepth. When the subtree holds more than maxTreeNodes entities, or goes
+// deeper than the depth, it is cut short and X-Tree-Truncated is set.
+func Tree(w http.ResponseWriter, r *http.Request) {
+
+	id := r.PathValue("id")
+
+	depth := maxTreeDepth
+	if raw := r.URL.Query().Get("depth"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			http.Error(w, "Invalid depth parameter", http.StatusBadRequest)
+			return
+		}
+		if parsed > maxTreeDepth {
+			http.Error(w, fmt.Sprintf("Depth has to be at most %d", maxTreeDepth), http.StatusBadRequest)
+			return
+		}
+		depth = parsed
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	tree, truncated, err := db.QuerySubtree(r.Context(), id, depth, maxTreeNodes)
+	if err != nil {
+		if errors.Is(err, database.ErrEntityNotFound) {
+			http.Error(w, "Entity not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		return
+	}
+
+	if truncated {
+		w.Header().Set("X-Tree-Truncated", "true")
+	}
+
+	writeJson(w, http.StatusOK, tree)
+}
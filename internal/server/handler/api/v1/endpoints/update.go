@@ -0,0 +1,119 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/models"
+	"Backend/internal/server/middleware"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// Update
+// accepts the same form-data fields as Create, fields that are omitted are left untouched.
+// Uploaded images are added to the existing ones and attributes are merged, null removes a key.
+// The quantity is not accepted here, it only changes through Adjust so that every change is recorded.
+// An empty min_quantity removes the low-stock threshold.
+func Update(w http.ResponseWriter, r *http.Request) {
+
+	id := r.PathValue("id")
+
+	// Parsing the request, assumes PATCH form-data
+	if !parseEntityForm(w, r) {
+		return
+	}
+
+	opts := make([]models.NewEntityOption, 0)
+
+	if name, ok := formValue(r, "name"); ok {
+		opts = append(opts, models.EntityWithName(name))
+	}
+	if description, ok := formValue(r, "description"); ok {
+		opts = append(opts, models.EntityWithDescription(description))
+	}
+	if raw, ok := formValue(r, "code"); ok && raw != "" {
+		code, err := models.NormalizeCode(raw)
+		if err != nil {
+			http.Error(w, "Invalid code, use 4 to 16 letters or digits", http.StatusBadRequest)
+			return
+		}
+		opts = append(opts, models.EntityWithCode(code))
+	}
+	attrs, hasAttrs, err := formAttributes(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if hasAttrs {
+		opts = append(opts, models.EntityWithMergedAttributes(attrs))
+	}
+	if unit, ok := formValue(r, "unit"); ok {
+		opts = append(opts, models.EntityWithUnit(unit))
+	}
+	if raw, ok := formValue(r, "min_quantity"); ok {
+		var minQuantity *int64
+		if raw != "" {
+			threshold, err := parseQuantity(raw)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			minQuantity = &threshold
+		}
+		opts = append(opts, models.EntityWithMinQuantity(minQuantity))
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	// Avoid uploading images for an entity that does not exist or a form that is rejected anyway
+	if _, err := db.QueryById(r.Context(), id); err != nil {
+		if errors.Is(err, database.ErrEntityNotFound) {
+			http.Error(w, "Entity not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		return
+	}
+	if err := checkFormAttributes(r, db, attrs); err != nil {
+		if errors.Is(err, models.ErrInvalidAttribute) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		return
+	}
+
+	// Extract images
+	thumbnails, err := uploadFormImages(r, id)
+	if err != nil {
+		http.Error(w, "Unable to generate thumbnails", http.StatusInternalServerError)
+		return
+	}
+	if len(thumbnails) > 0 {
+		opts = append(opts, models.EntityWithAddedImages(thumbnails))
+	}
+
+	entity, err := db.UpdateEntity(r.Context(), id, opts...)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrEntityNotFound):
+			http.Error(w, "Entity not found", http.StatusNotFound)
+		case errors.Is(err, database.ErrCodeInUse):
+			http.Error(w, "Code already assigned to another entity", http.StatusConflict)
+		case errors.Is(err, models.ErrInvalidAttribute):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Println(err)
+			http.Error(w, "Unable to update entity", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJson(w, http.StatusOK, entity)
+}
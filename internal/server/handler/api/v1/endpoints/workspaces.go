@@ -0,0 +1,221 @@
+package endpoints
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/models"
+	"Backend/internal/server/middleware"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+const maxWorkspaceNameLength = 100
+
+type workspaceRequest struct {
+	Name string `json:"name"`
+}
+
+type memberRequest struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+type roleRequest struct {
+	Role string `json:"role"`
+}
+
+func handleWorkspaceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, database.ErrWorkspaceNotFound):
+		http.Error(w, "Workspace not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrUserNotFound):
+		http.Error(w, "User not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrMemberExists):
+		http.Error(w, "User is already a member of the workspace", http.StatusConflict)
+	case errors.Is(err, database.ErrLastOwner):
+		http.Error(w, "A workspace needs at least one owner", http.StatusConflict)
+	default:
+		log.Printf("[Error] Unable to query DB, %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+	}
+}
+
+// parseRole
+// parses an optional role of a request body, falling back to the given one when empty.
+func parseRole(w http.ResponseWriter, raw string, fallback models.Role) (models.Role, bool) {
+	if raw == "" {
+		return fallback, true
+	}
+	role, err := models.ParseRole(raw)
+	if err != nil {
+		http.Error(w, "Role must be viewer, editor or owner", http.StatusBadRequest)
+		return "", false
+	}
+	return role, true
+}
+
+// ListWorkspaces
+// lists the workspaces of the user, the first one is used when no X-Workspace header is sent.
+func ListWorkspaces(w http.ResponseWriter, r *http.Request) {
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	workspaces, err := db.QueryWorkspacesOfUser(r.Context(), user.Id)
+	if err != nil {
+		handleWorkspaceError(w, err)
+		return
+	}
+
+	writeJson(w, http.StatusOK, workspaces)
+}
+
+// CreateWorkspace
+// expects a JSON body {"name": "..."}, the user becomes the first member.
+func CreateWorkspace(w http.ResponseWriter, r *http.Request) {
+
+	var req workspaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Request body is not valid JSON", http.StatusBadRequest)
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxWorkspaceNameLength {
+		http.Error(w, "Workspace name is required and at most 100 characters long", http.StatusBadRequest)
+		return
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	workspace, err := db.CreateWorkspace(r.Context(), name, user.Id)
+	if err != nil {
+		handleWorkspaceError(w, err)
+		return
+	}
+
+	writeJson(w, http.StatusCreated, workspace)
+}
+
+// ListWorkspaceMembers
+// lists the members of the workspace with their roles, see middleware.RequireWorkspaceRole.
+func ListWorkspaceMembers(w http.ResponseWriter, r *http.Request) {
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	members, err := db.QueryWorkspaceMembers(r.Context(), r.PathValue("id"))
+	if err != nil {
+		handleWorkspaceError(w, err)
+		return
+	}
+
+	writeJson(w, http.StatusOK, members)
+}
+
+// AddWorkspaceMember
+// expects a JSON body {"username": "...", "role": "editor"} of an existing user, the role
+// defaults to viewer. Only owners can add members.
+func AddWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+
+	var req memberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Request body is not valid JSON", http.StatusBadRequest)
+		return
+	}
+
+	role, ok := parseRole(w, req.Role, models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	member, err := db.AddWorkspaceMember(r.Context(), r.PathValue("id"), req.Username, role)
+	if err != nil {
+		handleWorkspaceError(w, err)
+		return
+	}
+
+	writeJson(w, http.StatusCreated, member)
+}
+
+// UpdateMemberRole
+// expects a JSON body {"role": "editor"}. Only owners can change roles.
+func UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+
+	var req roleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Request body is not valid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Role == "" {
+		http.Error(w, "Role is required", http.StatusBadRequest)
+		return
+	}
+
+	role, ok := parseRole(w, req.Role, "")
+	if !ok {
+		return
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	member, err := db.UpdateMemberRole(r.Context(), r.PathValue("id"), r.PathValue("userId"), role)
+	if err != nil {
+		handleWorkspaceError(w, err)
+		return
+	}
+
+	writeJson(w, http.StatusOK, member)
+}
+
+// RemoveWorkspaceMember
+// owners can remove anyone, other members only themselves to leave the workspace.
+func RemoveWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+		return
+	}
+
+	if err := db.RemoveWorkspaceMember(r.Context(), r.PathValue("id"), r.PathValue("userId")); err != nil {
+		handleWorkspaceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
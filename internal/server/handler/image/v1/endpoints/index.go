@@ -1,6 +1,8 @@
 package endpoints
 
 import (
+	"Backend/internal/database"
+	"Backend/internal/objectstore"
 	"Backend/internal/server/middleware"
 	"Backend/internal/thumbnail"
 	"errors"
@@ -14,8 +16,9 @@ import (
 )
 
 type imageDetails struct {
-	Name string
-	Size thumbnail.Size
+	WorkspaceId string // Empty for images uploaded before workspaces existed
+	Name        string
+	Size        thumbnail.Size
 }
 
 func (i *imageDetails) toFullName() (string, error) {
@@ -24,6 +27,9 @@ func (i *imageDetails) toFullName() (string, error) {
 		return "", err
 	}
 
+	if i.WorkspaceId != "" {
+		return fmt.Sprintf("%s/%s_%s.jpeg", i.WorkspaceId, i.Name, abvr), nil
+	}
 	return fmt.Sprintf("%s_%s.jpeg", i.Name, abvr), nil
 }
 
@@ -38,11 +44,17 @@ func getImageDetails(path *url.URL) (*imageDetails, error) {
 	cleanPath := strings.Trim(path.Path, "/")
 	seg := strings.Split(cleanPath, "/")
 
-	if len(seg) != 1 {
+	// Either <workspace>/<image>.jpeg or <image>.jpeg from before workspaces existed
+	if len(seg) != 1 && len(seg) != 2 {
 		return nil, errors.New("malformed path")
 	}
 
-	filename := seg[0]
+	workspaceId := ""
+	if len(seg) == 2 {
+		workspaceId = seg[0]
+	}
+
+	filename := seg[len(seg)-1]
 	if !strings.HasSuffix(filename, ".jpeg") {
 		return nil, errors.New("image type not supported")
 	}
@@ -58,11 +70,50 @@ func getImageDetails(path *url.URL) (*imageDetails, error) {
 	}
 
 	return &imageDetails{
-		Name: imgName,
-		Size: size,
+		WorkspaceId: workspaceId,
+		Name:        imgName,
+		Size:        size,
 	}, nil
 }
 
+// authorizeImage
+// checks that the user of the request is a member of the workspace the image belongs to, or
+// that the API key of the request is bound to it. Images from before workspaces existed belong
+// to the workspace of their entity, whose id starts the image name. Without a user or key,
+// i.e. when images are public, everything is allowed.
+func authorizeImage(r *http.Request, details *imageDetails) error {
+	user, isUser := middleware.GetUserFromContext(r.Context())
+	key, isKey := middleware.GetApiKeyFromContext(r.Context())
+	if !isUser && !isKey {
+		return nil
+	}
+
+	db, ok := middleware.GetDbFromContext(r.Context())
+	if !ok {
+		return errors.New("unable to load DB instance")
+	}
+
+	workspaceId := details.WorkspaceId
+	if workspaceId == "" {
+		entityId, _, _ := strings.Cut(details.Name, "_")
+		id, err := db.QueryEntityWorkspace(r.Context(), entityId)
+		if err != nil {
+			return err
+		}
+		workspaceId = id
+	}
+
+	if isKey {
+		if key.WorkspaceId != workspaceId {
+			return database.ErrWorkspaceNotFound
+		}
+		return nil
+	}
+
+	_, err := db.QueryMembership(r.Context(), workspaceId, user.Id)
+	return err
+}
+
 func Index(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("%v", r.URL.Path)
@@ -73,6 +124,16 @@ func Index(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Unable to parse image details", http.StatusInternalServerError)
 		return
 	}
+	if err := authorizeImage(r, imgDetails); err != nil {
+		if errors.Is(err, database.ErrWorkspaceNotFound) || errors.Is(err, database.ErrEntityNotFound) {
+			http.Error(w, "Image not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("[Error] Unable to authorize image access: %v", err)
+		http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+		return
+	}
+
 	imgToRetrieve, err := imgDetails.toFullName()
 	if err != nil {
 		log.Printf("[Error] Unable to derive full image name: %v", err)
@@ -89,6 +150,10 @@ func Index(w http.ResponseWriter, r *http.Request) {
 
 	imgData, err := objStore.RetrieveImage(r.Context(), imgToRetrieve)
 	if err != nil {
+		if errors.Is(err, objectstore.ErrObjectNotFound) {
+			http.Error(w, "Image not found", http.StatusNotFound)
+			return
+		}
 		log.Printf("[Error] Unable to retrieve object")
 		http.Error(w, "Unable to retrieve object", http.StatusInternalServerError)
 		return
@@ -105,7 +170,14 @@ func Index(w http.ResponseWriter, r *http.Request) {
 	contentType := http.DetectContentType(img)
 	w.Header().Set("Content-Type", contentType)
 	w.Header().Set("Content-Length", strconv.Itoa(len(img)))
-	w.Header().Set("Cache-Control", "public, max-age=86400") // one day, 60 * 60 * 24
+	// one day, 60 * 60 * 24, shared caches must not keep images that need a login
+	_, isUser := middleware.GetUserFromContext(r.Context())
+	_, isKey := middleware.GetApiKeyFromContext(r.Context())
+	if isUser || isKey {
+		w.Header().Set("Cache-Control", "private, max-age=86400")
+	} else {
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+	}
 	w.WriteHeader(http.StatusOK)
 
 	if _, err := w.Write(img); err != nil {
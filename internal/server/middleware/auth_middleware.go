@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/models"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// AuthCookieName holds the token for clients that cannot send headers, e.g. <img> tags
+const AuthCookieName = "tt_token"
+
+type authConfig struct {
+	publicPaths map[string]bool
+	allowCookie bool
+}
+
+type AuthOption func(config *authConfig)
+
+// AuthWithPublicPaths
+// lets requests to the given paths through without a token, e.g. the login itself.
+func AuthWithPublicPaths(paths ...string) AuthOption {
+	return func(c *authConfig) {
+		for _, p := range paths {
+			c.publicPaths[p] = true
+		}
+	}
+}
+
+// AuthWithCookie
+// also accepts the token from the AuthCookieName cookie.
+func AuthWithCookie() AuthOption {
+	return func(c *authConfig) {
+		c.allowCookie = true
+	}
+}
+
+// ApplyAuthentication
+// rejects requests without a valid token with 401. The user is attached to the context,
+// see GetUserFromContext, and changes are recorded in the entity history under their name.
+// API keys are accepted the same way and attached instead of a user, see GetApiKeyFromContext.
+func ApplyAuthentication(db database.Repository, opts ...AuthOption) ApplyMiddlewareLayer {
+	config := &authConfig{publicPaths: make(map[string]bool)}
+	for _, o := range opts {
+		o(config)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if config.publicPaths[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, ok := BearerToken(r)
+			if !ok && config.allowCookie {
+				if cookie, err := r.Cookie(AuthCookieName); err == nil && cookie.Value != "" {
+					token, ok = cookie.Value, true
+				}
+			}
+			if !ok {
+				unauthorized(w)
+				return
+			}
+
+			ctx, err := authenticate(r.Context(), db, token)
+			if err != nil {
+				if errors.Is(err, database.ErrInvalidToken) {
+					unauthorized(w)
+					return
+				}
+				log.Printf("[Error] Unable to verify token, %v", err)
+				http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// authenticate
+// attaches the user of a login token or the API key to the context.
+func authenticate(ctx context.Context, db database.Repository, token string) (context.Context, error) {
+	if models.IsApiKey(token) {
+		key, err := db.QueryApiKeyByToken(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		ctx = context.WithValue(ctx, ContextKeyApiKey, key)
+		return database.WithActor(ctx, "api-key:"+key.Name), nil
+	}
+
+	user, err := db.QueryUserByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, ContextKeyUser, user)
+	return database.WithActor(ctx, user.Username), nil
+}
+
+func GetUserFromContext(ctx context.Context) (*models.User, bool) {
+	user, ok := ctx.Value(ContextKeyUser).(*models.User)
+	return user, ok
+}
+
+func GetApiKeyFromContext(ctx context.Context) (*models.ApiKey, bool) {
+	key, ok := ctx.Value(ContextKeyApiKey).(*models.ApiKey)
+	return key, ok
+}
+
+// BearerToken
+// returns the token of an "Authorization: Bearer <token>" header.
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="tag-track"`)
+	http.Error(w, "Authentication required", http.StatusUnauthorized)
+}
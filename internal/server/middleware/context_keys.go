@@ -4,3 +4,5 @@ type ContextKey string
 
 const ContextKeyDb ContextKey = "db"
 const ContextKeyObjStore ContextKey = "objStore"
+const ContextKeyUser ContextKey = "user"
+const ContextKeyApiKey ContextKey = "apiKey"
@@ -6,7 +6,7 @@ import (
 	"net/http"
 )
 
-func ApplyAttachDb(db *database.GormPgAdapter) ApplyMiddlewareLayer {
+func ApplyAttachDb(db database.Repository) ApplyMiddlewareLayer {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := context.WithValue(r.Context(), ContextKeyDb, db)
@@ -15,7 +15,7 @@ func ApplyAttachDb(db *database.GormPgAdapter) ApplyMiddlewareLayer {
 	}
 }
 
-func GetDbFromContext(ctx context.Context) (*database.GormPgAdapter, bool) {
-	db, ok := ctx.Value(ContextKeyDb).(*database.GormPgAdapter)
+func GetDbFromContext(ctx context.Context) (database.Repository, bool) {
+	db, ok := ctx.Value(ContextKeyDb).(database.Repository)
 	return db, ok
 }
@@ -6,7 +6,7 @@ import (
 	"net/http"
 )
 
-func ApplyAttachObjStore(db *objectstore.MinioAdapter) ApplyMiddlewareLayer {
+func ApplyAttachObjStore(db objectstore.ObjectStore) ApplyMiddlewareLayer {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := context.WithValue(r.Context(), ContextKeyObjStore, db)
@@ -15,7 +15,7 @@ func ApplyAttachObjStore(db *objectstore.MinioAdapter) ApplyMiddlewareLayer {
 	}
 }
 
-func GetObjStoreFromContext(ctx context.Context) (*objectstore.MinioAdapter, bool) {
-	db, ok := ctx.Value(ContextKeyObjStore).(*objectstore.MinioAdapter)
+func GetObjStoreFromContext(ctx context.Context) (objectstore.ObjectStore, bool) {
+	db, ok := ctx.Value(ContextKeyObjStore).(objectstore.ObjectStore)
 	return db, ok
 }
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// errorResponse is the JSON body of requests a middleware rejects
+type errorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// writeError
+// answers with the status and a JSON body, error is the status text in snake case, e.g. forbidden.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJson(w, status, errorResponse{
+		Error:   strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_")),
+		Message: message,
+	})
+}
+
+func writeJson(w http.ResponseWriter, status int, body any) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		log.Printf("[Error] Unable to create response, %v", err)
+		http.Error(w, "Unable to create response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Printf("[Error] Unable to write response, %v", err)
+	}
+}
@@ -0,0 +1,253 @@
+package middleware
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/models"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+)
+
+// maxRoleBodySize caps the JSON bodies EntityFromJson reads ahead of the handler
+const maxRoleBodySize = 1 << 20 // 1 MB
+
+// RoleTarget
+// returns the id of the entity a request acts on. An empty id checks the workspace role,
+// e.g. for entities created or moved to the top level.
+type RoleTarget func(r *http.Request) string
+
+// EntityFromPath
+// targets the entity named by a path value of the route, e.g. {id}.
+func EntityFromPath(name string) RoleTarget {
+	return func(r *http.Request) string {
+		return r.PathValue(name)
+	}
+}
+
+// EntityFromForm
+// targets the entity named by a multipart form field. The form is parsed with the same
+// maxMemory as the handler uses, so that the handler gets the already parsed form.
+// A form that cannot be parsed targets the workspace, the handler reports the error.
+func EntityFromForm(field string, maxMemory int64) RoleTarget {
+	return func(r *http.Request) string {
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			return ""
+		}
+		return r.FormValue(field)
+	}
+}
+
+// EntityFromJson
+// targets the entity named by a string field of a JSON body, a null or missing field
+// targets the workspace. The body is put back for the handler to decode.
+func EntityFromJson(field string) RoleTarget {
+	return func(r *http.Request) string {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxRoleBodySize))
+		if err != nil {
+			return ""
+		}
+		r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return ""
+		}
+		var id *string
+		if err := json.Unmarshal(fields[field], &id); err != nil || id == nil {
+			return ""
+		}
+		return *id
+	}
+}
+
+type roleError struct {
+	Error        string      `json:"error"`
+	Message      string      `json:"message"`
+	RequiredRole models.Role `json:"required_role"`
+	Role         models.Role `json:"role,omitempty"`
+	EntityId     string      `json:"entity_id,omitempty"`
+}
+
+// RequireRole
+// rejects the request with 403 unless the user holds the role on every target, taking grants
+// on the targets and their ancestors into account. Without targets the workspace role is
+// checked. API keys hold the role of their scope throughout their workspace.
+// Wraps single routes and has to run after ApplyWorkspace and ApplyAttachDb.
+func RequireRole(required models.Role, targets ...RoleTarget) ApplyMiddlewareLayer {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key, ok := GetApiKeyFromContext(r.Context()); ok {
+				if role := key.Scope.Role(); !role.Allows(required) {
+					WriteForbidden(w, required, role, "")
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			user, ok := GetUserFromContext(r.Context())
+			if !ok {
+				unauthorized(w)
+				return
+			}
+
+			db, ok := GetDbFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+				return
+			}
+
+			entityIds := []string{""}
+			if len(targets) > 0 {
+				entityIds = entityIds[:0]
+				for _, target := range targets {
+					entityIds = append(entityIds, target(r))
+				}
+			}
+
+			for _, entityId := range entityIds {
+				role, err := db.EffectiveRole(r.Context(), user.Id, entityId)
+				if err != nil && !errors.Is(err, database.ErrWorkspaceNotFound) && !errors.Is(err, database.ErrNoWorkspace) {
+					log.Printf("[Error] Unable to resolve role, %v", err)
+					http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+					return
+				}
+				if !role.Allows(required) {
+					WriteForbidden(w, required, role, entityId)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RestrictTagCreation
+// lets the request create tags only when the user holds the role in the workspace. Tags are
+// shared by the whole workspace, so a grant on a subtree does not allow creating them.
+// Without the role the database only attaches existing tags, see database.WithoutTagCreation.
+// Wraps single routes and has to run after ApplyWorkspace and ApplyAttachDb.
+func RestrictTagCreation(required models.Role) ApplyMiddlewareLayer {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key, ok := GetApiKeyFromContext(r.Context()); ok {
+				if !key.Scope.Role().Allows(required) {
+					r = r.WithContext(database.WithoutTagCreation(r.Context()))
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			user, ok := GetUserFromContext(r.Context())
+			if !ok {
+				unauthorized(w)
+				return
+			}
+
+			db, ok := GetDbFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+				return
+			}
+
+			role, err := db.EffectiveRole(r.Context(), user.Id, "")
+			if err != nil && !errors.Is(err, database.ErrWorkspaceNotFound) && !errors.Is(err, database.ErrNoWorkspace) {
+				log.Printf("[Error] Unable to resolve role, %v", err)
+				http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+				return
+			}
+			if !role.Allows(required) {
+				r = r.WithContext(database.WithoutTagCreation(r.Context()))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WriteForbidden
+// answers with 403 and a JSON body naming the role that was required and the one the user has.
+func WriteForbidden(w http.ResponseWriter, required models.Role, actual models.Role, entityId string) {
+	message := "The " + string(required) + " role is required"
+	if entityId != "" {
+		message += " on this entity"
+	}
+
+	writeJson(w, http.StatusForbidden, roleError{
+		Error:        "forbidden",
+		Message:      message,
+		RequiredRole: required,
+		Role:         actual,
+		EntityId:     entityId,
+	})
+}
+
+type workspaceRoleConfig struct {
+	selfParam string
+}
+
+type WorkspaceRoleOption func(config *workspaceRoleConfig)
+
+// WorkspaceRoleExceptSelf
+// lets members act on themselves with any role, when the path value names their own user id.
+func WorkspaceRoleExceptSelf(param string) WorkspaceRoleOption {
+	return func(c *workspaceRoleConfig) {
+		c.selfParam = param
+	}
+}
+
+// RequireWorkspaceRole
+// rejects the request with 403 unless the user is a member of the {id} workspace of the
+// path holding the role. Unlike RequireRole it does not go by the workspace of the request,
+// it guards the routes managing a workspace. API keys cannot manage workspaces.
+// Wraps single routes and has to run after ApplyAuthentication and ApplyAttachDb.
+func RequireWorkspaceRole(required models.Role, opts ...WorkspaceRoleOption) ApplyMiddlewareLayer {
+	config := &workspaceRoleConfig{}
+	for _, o := range opts {
+		o(config)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := GetApiKeyFromContext(r.Context()); ok {
+				writeError(w, http.StatusForbidden, "API keys cannot manage workspaces")
+				return
+			}
+
+			user, ok := GetUserFromContext(r.Context())
+			if !ok {
+				unauthorized(w)
+				return
+			}
+
+			db, ok := GetDbFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Unable to load DB instance", http.StatusInternalServerError)
+				return
+			}
+
+			member, err := db.QueryMembership(r.Context(), r.PathValue("id"), user.Id)
+			if err != nil {
+				if errors.Is(err, database.ErrWorkspaceNotFound) {
+					writeError(w, http.StatusForbidden, "Not a member of this workspace")
+					return
+				}
+				log.Printf("[Error] Unable to check workspace membership, %v", err)
+				http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+				return
+			}
+
+			self := config.selfParam != "" && r.PathValue(config.selfParam) == user.Id
+			if !self && !member.Role.Allows(required) {
+				WriteForbidden(w, required, member.Role, "")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
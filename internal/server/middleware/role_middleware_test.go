@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/models"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestRequireWorkspaceRole(t *testing.T) {
+	db, err := database.CreateGormSqliteAdapter(filepath.Join(t.TempDir(), "tt.db"))
+	if err != nil {
+		t.Fatalf("unable to create adapter: %v", err)
+	}
+	ctx := context.Background()
+	if err := db.Migrate(ctx); err != nil {
+		t.Fatalf("unable to migrate: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Disconnect(ctx) })
+
+	users := map[string]*models.User{}
+	for _, name := range []string{"alice", "bob", "carol"} {
+		user, err := models.NewUser(name, "correct horse")
+		if err != nil {
+			t.Fatalf("unable to create user: %v", err)
+		}
+		if err := db.CreateUser(ctx, user); err != nil {
+			t.Fatalf("unable to store user: %v", err)
+		}
+		users[name] = user
+	}
+	workspace, err := db.CreateWorkspace(ctx, "Home", users["alice"].Id)
+	if err != nil {
+		t.Fatalf("unable to create workspace: %v", err)
+	}
+	if _, err := db.AddWorkspaceMember(ctx, workspace.Id, "bob", models.RoleViewer); err != nil {
+		t.Fatalf("unable to add member: %v", err)
+	}
+
+	router := http.NewServeMux()
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	router.Handle("POST /workspaces/{id}/members", RequireWorkspaceRole(models.RoleOwner)(ok))
+	router.Handle("DELETE /workspaces/{id}/members/{userId}",
+		RequireWorkspaceRole(models.RoleOwner, WorkspaceRoleExceptSelf("userId"))(ok))
+	handler := ApplyAttachDb(db)(router)
+
+	members := "/workspaces/" + workspace.Id + "/members"
+	for _, c := range []struct {
+		user   string
+		method string
+		path   string
+		want   int
+	}{
+		{"alice", http.MethodPost, members, http.StatusNoContent},
+		{"bob", http.MethodPost, members, http.StatusForbidden},
+		{"carol", http.MethodPost, members, http.StatusForbidden},
+		{"bob", http.MethodDelete, members + "/" + users["alice"].Id, http.StatusForbidden},
+		{"bob", http.MethodDelete, members + "/" + users["bob"].Id, http.StatusNoContent},
+		{"alice", http.MethodDelete, members + "/" + users["bob"].Id, http.StatusNoContent},
+		{"carol", http.MethodDelete, members + "/" + users["carol"].Id, http.StatusForbidden},
+	} {
+		r := httptest.NewRequest(c.method, c.path, nil)
+		r = r.WithContext(context.WithValue(r.Context(), ContextKeyUser, users[c.user]))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		if rec.Code != c.want {
+			t.Errorf("%s %s by %s = %d, want %d", c.method, c.path, c.user, rec.Code, c.want)
+		}
+		if rec.Code == http.StatusForbidden && rec.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%s %s by %s answered 403 without a JSON body", c.method, c.path, c.user)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodPost, members, nil)
+	r = r.WithContext(context.WithValue(r.Context(), ContextKeyApiKey, &models.ApiKey{WorkspaceId: workspace.Id, Scope: models.ApiKeyScopeWrite}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("adding a member with an API key = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestRestrictTagCreation(t *testing.T) {
+	db, err := database.CreateGormSqliteAdapter(filepath.Join(t.TempDir(), "tt.db"))
+	if err != nil {
+		t.Fatalf("unable to create adapter: %v", err)
+	}
+	ctx := context.Background()
+	if err := db.Migrate(ctx); err != nil {
+		t.Fatalf("unable to migrate: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Disconnect(ctx) })
+
+	users := map[string]*models.User{}
+	for _, name := range []string{"alice", "bob"} {
+		user, err := models.NewUser(name, "correct horse")
+		if err != nil {
+			t.Fatalf("unable to create user: %v", err)
+		}
+		if err := db.CreateUser(ctx, user); err != nil {
+			t.Fatalf("unable to store user: %v", err)
+		}
+		users[name] = user
+	}
+	workspace, err := db.CreateWorkspace(ctx, "Home", users["alice"].Id)
+	if err != nil {
+		t.Fatalf("unable to create workspace: %v", err)
+	}
+	if _, err := db.AddWorkspaceMember(ctx, workspace.Id, "bob", models.RoleViewer); err != nil {
+		t.Fatalf("unable to add member: %v", err)
+	}
+	wsCtx := database.WithWorkspace(ctx, workspace.Id)
+	if err := db.CreateEntity(wsCtx, models.NewEntity(models.EntityWithId("cabinet"), models.EntityWithName("Cabinet"))); err != nil {
+		t.Fatalf("unable to create entity: %v", err)
+	}
+	if _, err := db.GrantRole(wsCtx, "cabinet", users["bob"].Id, models.RoleEditor); err != nil {
+		t.Fatalf("unable to grant: %v", err)
+	}
+
+	attach := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := db.AttachTags(r.Context(), "cabinet", r.URL.Query().Get("tag"))
+		switch {
+		case errors.Is(err, database.ErrTagCreationDenied):
+			w.WriteHeader(http.StatusForbidden)
+		case err != nil:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	handler := ApplyAttachDb(db)(RestrictTagCreation(models.RoleEditor)(attach))
+
+	for _, c := range []struct {
+		user string
+		tag  string
+		want int
+	}{
+		{"bob", "lab", http.StatusForbidden},
+		{"alice", "lab", http.StatusNoContent},
+		{"bob", "lab", http.StatusNoContent},
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/entities/cabinet/tags?tag="+c.tag, nil)
+		rctx := context.WithValue(database.WithWorkspace(r.Context(), workspace.Id), ContextKeyUser, users[c.user])
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r.WithContext(rctx))
+
+		if rec.Code != c.want {
+			t.Errorf("attaching %s by %s = %d, want %d", c.tag, c.user, rec.Code, c.want)
+		}
+	}
+}
@@ -1,30 +1,146 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
+	"log"
 	"net/http"
+	"sync"
 	"time"
 )
 
-func ApplyTimeout(duration time.Duration) ApplyMiddlewareLayer {
+type timeoutConfig struct {
+	handlers *sync.WaitGroup
+	routes   *http.ServeMux
+	limits   map[string]time.Duration
+}
+
+type TimeoutOption func(config *timeoutConfig)
+
+// TimeoutWithWaitGroup
+// counts the handlers in the group. A handler keeps running after its request timed out
+// until it notices that its context is done, so shutdown waits on the group for them.
+func TimeoutWithWaitGroup(wg *sync.WaitGroup) TimeoutOption {
+	return func(c *timeoutConfig) {
+		c.handlers = wg
+	}
+}
+
+// TimeoutForRoutes
+// gives requests matching one of the ServeMux patterns, e.g. "POST /create", their own
+// timeout instead of the default one. Meant for routes that upload or render a lot.
+func TimeoutForRoutes(duration time.Duration, patterns ...string) TimeoutOption {
+	return func(c *timeoutConfig) {
+		if c.routes == nil {
+			c.routes = http.NewServeMux()
+			c.limits = map[string]time.Duration{}
+		}
+		for _, pattern := range patterns {
+			c.routes.Handle(pattern, http.NotFoundHandler())
+			c.limits[pattern] = duration
+		}
+	}
+}
+
+// ApplyTimeout
+// answers with 504 when the handler takes longer than the duration. Like http.TimeoutHandler
+// the response of the handler is buffered and only sent when it finishes in time, whatever
+// it writes after the timeout is discarded.
+func ApplyTimeout(duration time.Duration, opts ...TimeoutOption) ApplyMiddlewareLayer {
+	config := &timeoutConfig{}
+	for _, o := range opts {
+		o(config)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ctx, cancel := context.WithTimeout(r.Context(), duration)
+			limit := duration
+			if config.routes != nil {
+				if _, pattern := config.routes.Handler(r); pattern != "" {
+					limit = config.limits[pattern]
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), limit)
 			defer cancel()
 
+			tw := &timeoutWriter{header: make(http.Header)}
 			done := make(chan struct{})
+			panicked := make(chan any, 1)
 
+			if config.handlers != nil {
+				config.handlers.Add(1)
+			}
 			go func() {
-				next.ServeHTTP(w, r.WithContext(ctx))
+				if config.handlers != nil {
+					defer config.handlers.Done()
+				}
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
 				close(done)
 			}()
 
 			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				for key, values := range tw.header {
+					w.Header()[key] = values
+				}
+				if tw.code == 0 {
+					tw.code = http.StatusOK
+				}
+				w.WriteHeader(tw.code)
+				if _, err := w.Write(tw.body.Bytes()); err != nil {
+					log.Printf("[Error] unable to write response: %v", err)
+				}
 			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
 				http.Error(w, "Request timed out", http.StatusGatewayTimeout)
-			case <-done:
-				return
 			}
 		})
 	}
 }
+
+// timeoutWriter
+// buffers the response of a handler running under ApplyTimeout.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	code     int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	return tw.body.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
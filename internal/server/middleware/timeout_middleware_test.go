@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestApplyTimeoutDiscardsLateResponses(t *testing.T) {
+	var handlers sync.WaitGroup
+	handler := ApplyTimeout(20*time.Millisecond, TimeoutWithWaitGroup(&handlers))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+			// Still writing after the timeout, the response must not reach the client
+			w.Header().Set("X-Late", "true")
+			w.WriteHeader(http.StatusCreated)
+			for i := 0; i < 100; i++ {
+				_, _ = w.Write([]byte("late"))
+			}
+		}),
+	)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	handlers.Wait()
+
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusGatewayTimeout)
+	}
+	if rec.Header().Get("X-Late") != "" {
+		t.Error("a header set after the timeout reached the client")
+	}
+	if body := rec.Body.String(); body != "Request timed out\n" {
+		t.Errorf("body = %q, want the timeout message only", body)
+	}
+}
+
+func TestApplyTimeoutSendsBufferedResponse(t *testing.T) {
+	handler := ApplyTimeout(time.Second)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("created"))
+		}),
+	)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	if rec.Code != http.StatusCreated || rec.Body.String() != "created" || rec.Header().Get("Content-Type") != "text/plain" {
+		t.Errorf("got %d %q %v, want the response of the handler", rec.Code, rec.Body.String(), rec.Header())
+	}
+}
+
+func TestTimeoutForRoutes(t *testing.T) {
+	handler := ApplyTimeout(
+		10*time.Millisecond,
+		TimeoutForRoutes(time.Second, "POST /create", "PATCH /entities/{id}"),
+	)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(50 * time.Millisecond):
+				w.WriteHeader(http.StatusNoContent)
+			case <-r.Context().Done():
+			}
+		}),
+	)
+
+	for _, c := range []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodPost, "/create", http.StatusNoContent},
+		{http.MethodPatch, "/entities/abc", http.StatusNoContent},
+		{http.MethodGet, "/entities/abc", http.StatusGatewayTimeout},
+		{http.MethodGet, "/query", http.StatusGatewayTimeout},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, nil))
+		if rec.Code != c.want {
+			t.Errorf("%s %s = %d, want %d", c.method, c.path, rec.Code, c.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"Backend/internal/database"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+const headerWorkspace = "X-Workspace"
+
+// ApplyWorkspace
+// scopes the request to the workspace named in the X-Workspace header, or to the first
+// workspace the user joined when the header is omitted. Requests to a workspace the user
+// is not a member of are rejected with a JSON 403. Paths under one of the optional prefixes are
+// let through without a workspace, e.g. the ones to list or create workspaces.
+// API keys are bound to their workspace, naming another one in the header is rejected with 403.
+// Has to run after ApplyAuthentication, requests without a user are passed on untouched.
+func ApplyWorkspace(db database.Repository, optionalPrefixes ...string) ApplyMiddlewareLayer {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key, ok := GetApiKeyFromContext(r.Context()); ok {
+				if header := strings.TrimSpace(r.Header.Get(headerWorkspace)); header != "" && header != key.WorkspaceId {
+					writeError(w, http.StatusForbidden, "API key is not valid for this workspace")
+					return
+				}
+				ctx := database.WithWorkspace(r.Context(), key.WorkspaceId)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			user, ok := GetUserFromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			optional := false
+			for _, prefix := range optionalPrefixes {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					optional = true
+					break
+				}
+			}
+
+			workspaceId := strings.TrimSpace(r.Header.Get(headerWorkspace))
+			if workspaceId != "" {
+				if _, err := db.QueryMembership(r.Context(), workspaceId, user.Id); err != nil {
+					if errors.Is(err, database.ErrWorkspaceNotFound) {
+						writeError(w, http.StatusForbidden, "Not a member of this workspace")
+						return
+					}
+					log.Printf("[Error] Unable to check workspace membership, %v", err)
+					http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+					return
+				}
+			} else {
+				workspaces, err := db.QueryWorkspacesOfUser(r.Context(), user.Id)
+				if err != nil {
+					log.Printf("[Error] Unable to query workspaces, %v", err)
+					http.Error(w, "Unable to fulfill request", http.StatusInternalServerError)
+					return
+				}
+				if len(workspaces) > 0 {
+					workspaceId = workspaces[0].Id
+				}
+			}
+
+			if workspaceId == "" {
+				if optional {
+					next.ServeHTTP(w, r)
+					return
+				}
+				writeError(w, http.StatusForbidden, "Not a member of any workspace")
+				return
+			}
+
+			ctx := database.WithWorkspace(r.Context(), workspaceId)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -3,44 +3,139 @@ package server
 import (
 	"Backend/internal/database"
 	"Backend/internal/env"
+	"Backend/internal/models"
 	"Backend/internal/objectstore"
 	apiV1 "Backend/internal/server/handler/api/v1"
+	"Backend/internal/server/handler/api/v1/endpoints"
 	imageV1 "Backend/internal/server/handler/image/v1"
 	"Backend/internal/server/middleware"
+	"Backend/internal/trash"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 )
 
-func createDbInstance() *database.GormPgAdapter {
+// uploadTimeout bounds API requests that upload images, it has to stay below SERVER_WRITE_TIMEOUT
+const uploadTimeout = 30 * time.Second
+
+// renderTimeout bounds API requests that render a label sheet, which takes about a second
+// for the 300 labels allowed on fast hardware. It has to stay below SERVER_WRITE_TIMEOUT too.
+const renderTimeout = 30 * time.Second
+
+// OpenDatabase
+// creates the repository selected by DB_DRIVER, without migrating it.
+func OpenDatabase() (database.Repository, error) {
 
 	e := env.GetStaticEnv()
 
-	db, err := database.CreateGormPgAdapter(
-		e.DbHost,
-		e.DbUser,
-		e.DbPassword,
-		e.DbPort,
-		e.DbName,
-	)
+	switch e.DbDriver {
+	case "postgres":
+		return database.CreateGormPgAdapter(
+			e.DbHost,
+			e.DbUser,
+			e.DbPassword,
+			e.DbPort,
+			e.DbName,
+		)
+	case "sqlite":
+		return database.CreateGormSqliteAdapter(e.DbPath)
+	default:
+		return nil, fmt.Errorf("unknown database driver %q, use postgres or sqlite", e.DbDriver)
+	}
+}
+
+func createDbInstance(ctx context.Context) (database.Repository, error) {
+
+	db, err := OpenDatabase()
 	if err != nil {
-		log.Fatalln(err)
+		return nil, err
+	}
+	log.Printf("Using the %s database", env.GetStaticEnv().DbDriver)
+
+	// Also refuses to start on a schema from a newer binary, see database.ErrSchemaTooNew
+	if err := db.Migrate(ctx); err != nil {
+		_ = db.Disconnect(ctx)
+		return nil, err
+	}
+
+	if err := bootstrapAdmin(ctx, db); err != nil {
+		_ = db.Disconnect(ctx)
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// bootstrapAdmin
+// creates the ADMIN_USERNAME account on a database without users, so that a fresh installation can be logged into.
+func bootstrapAdmin(ctx context.Context, db database.Repository) error {
+
+	e := env.GetStaticEnv()
+	if e.AdminPassword == "" {
+		log.Println("[Warning] ADMIN_PASSWORD is not set, no admin account is created on an empty database")
+		return nil
 	}
 
-	if err := db.Migrate(context.Background()); err != nil {
-		log.Fatalln(err)
+	admin, err := models.NewUser(e.AdminUsername, e.AdminPassword)
+	if err != nil {
+		return fmt.Errorf("invalid admin credentials, %w", err)
 	}
 
-	return db
+	created, err := db.EnsureAdmin(ctx, admin)
+	if err != nil {
+		return err
+	}
+	if created {
+		log.Printf("Created admin account %q", admin.Username)
+	}
+	return nil
 }
 
-func Serve() {
+// Serve
+// runs the server until SIGINT or SIGTERM. It then stops accepting connections, waits for
+// in-flight requests, handlers that outlived their timeout and the trash janitor until
+// SHUTDOWN_TIMEOUT has passed, and disconnects from the database. When they are not done
+// by then the database is left connected for them and Serve fails, so that the process
+// exits non-zero. A second signal exits at once.
+// It fails when the server cannot start or does not shut down cleanly.
+func Serve() error {
 
 	e := env.GetStaticEnv()
-	db := createDbInstance()
-	objStore := objectstore.NewMinioAdapter()
+	for _, err := range []error{e.CheckPublicBaseUrl(), e.CheckLabelTargetUrl()} {
+		if err != nil {
+			log.Printf("[Warning] Labels are unavailable, %v", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := createDbInstance(ctx)
+	if err != nil {
+		return err
+	}
+	objStore, err := objectstore.NewObjectStore()
+	if err != nil {
+		_ = db.Disconnect(context.Background())
+		return err
+	}
+	log.Printf("Using the %s object store", e.ObjectStore)
+
+	// Handlers and jobs that shutdown waits for
+	var background sync.WaitGroup
+
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		trash.RunJanitor(ctx, db, objStore, e.TrashRetention, e.TrashPurgeInterval)
+	}()
 
 	mainRouter := http.NewServeMux()
 
@@ -51,31 +146,112 @@ func Serve() {
 				"/api/v1",
 				middleware.Apply(
 					apiV1.Router(),
-					middleware.ApplyTimeout(1500*time.Millisecond),
+					middleware.ApplyTimeout(
+						1500*time.Millisecond,
+						middleware.TimeoutWithWaitGroup(&background),
+						// Creating and updating entities uploads their images with all thumbnails
+						middleware.TimeoutForRoutes(uploadTimeout, "POST /create", "PATCH /entities/{id}"),
+						middleware.TimeoutForRoutes(renderTimeout, "POST /labels/sheet"),
+					),
+					middleware.ApplyWorkspace(db, "/workspaces", "/me", "/logout"),
+					middleware.ApplyAuthentication(db, middleware.AuthWithPublicPaths("/login")),
 					middleware.ApplyAttachObjStore(objStore),
 					middleware.ApplyAttachDb(db),
 				),
 			),
 		)
 
+	imageLayers := []middleware.ApplyMiddlewareLayer{
+		middleware.ApplyTimeout(200*time.Millisecond, middleware.TimeoutWithWaitGroup(&background)),
+		middleware.ApplyAttachObjStore(objStore),
+	}
+	if !e.ImagePublic {
+		imageLayers = append(
+			imageLayers,
+			middleware.ApplyAuthentication(db, middleware.AuthWithCookie()),
+			middleware.ApplyAttachDb(db),
+		)
+	}
+
 	mainRouter.
 		Handle(
 			"/image/v1/",
 			http.StripPrefix(
 				"/image/v1",
-				middleware.Apply(
-					imageV1.Router(),
-					middleware.ApplyTimeout(200*time.Millisecond),
-					middleware.ApplyAttachObjStore(objStore),
-				),
+				middleware.Apply(imageV1.Router(), imageLayers...),
+			),
+		)
+
+	// Printed on labels, which have to keep working however the API changes
+	mainRouter.
+		Handle(
+			"GET /r/{code}",
+			middleware.Apply(
+				http.HandlerFunc(endpoints.OpenLabel),
+				middleware.ApplyTimeout(200*time.Millisecond, middleware.TimeoutWithWaitGroup(&background)),
 			),
 		)
 
 	loggedRouter := middleware.LoggingMiddleware(mainRouter)
 
-	log.Printf("Starting server on 0.0.0.0:%v", e.ServerPort)
-	_ = http.ListenAndServe(
-		fmt.Sprintf("0.0.0.0:%v", e.ServerPort),
-		loggedRouter,
-	)
+	srv := &http.Server{
+		Addr:         fmt.Sprintf("0.0.0.0:%v", e.ServerPort),
+		Handler:      loggedRouter,
+		ReadTimeout:  e.ServerReadTimeout,
+		WriteTimeout: e.ServerWriteTimeout,
+		IdleTimeout:  e.ServerIdleTimeout,
+	}
+
+	listenErr := make(chan error, 1)
+	go func() {
+		log.Printf("Starting server on %s", srv.Addr)
+		listenErr <- srv.ListenAndServe()
+	}()
+
+	var errs []error
+	select {
+	case err := <-listenErr:
+		errs = append(errs, fmt.Errorf("unable to serve: %w", err))
+		stop()
+	case <-ctx.Done():
+		// Restores the default handling, a second signal kills the process
+		stop()
+		log.Printf("Shutting down, waiting up to %v for requests to finish", e.ShutdownTimeout)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), e.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, fmt.Errorf("unable to drain connections: %w", err))
+	}
+	if err := waitFor(shutdownCtx, &background); err != nil {
+		// Handlers or the janitor are still running, they would fail half way without a database
+		log.Printf("[Error] background work did not finish within %v, exiting without disconnecting", e.ShutdownTimeout)
+		errs = append(errs, fmt.Errorf("unable to finish background work: %w", err))
+	} else if err := db.Disconnect(context.Background()); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 {
+		log.Println("Server stopped")
+	}
+	return errors.Join(errs...)
+}
+
+// waitFor
+// waits for the group until the context is done.
+func waitFor(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
@@ -11,9 +11,7 @@ import (
 	"image"
 	"image/jpeg"
 	"image/png"
-	"io"
 	"math"
-	"mime/multipart"
 	"net/http"
 )
 
@@ -62,12 +60,7 @@ func getOrientationFromExif(exifData []byte) (int, error) {
 	return tag.Int(0)
 }
 
-func convertFileToJpeg(file multipart.File) (image.Image, error) {
-	data, err := io.ReadAll(file)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
-	}
-
+func decodeImage(data []byte) (image.Image, error) {
 	imageType, errDet := detectImageTypeFromBytes(data)
 	if errDet != nil {
 		return nil, errDet
@@ -77,6 +70,7 @@ func convertFileToJpeg(file multipart.File) (image.Image, error) {
 	}
 
 	var img image.Image
+	var err error
 	switch imageType {
 	case ImageTypeJPEG:
 		img, err = jpeg.Decode(bytes.NewReader(data))
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"github.com/lucsky/cuid"
+	"io"
 	"mime/multipart"
+	"strings"
 	"sync"
 )
 
@@ -58,6 +60,7 @@ func (s Size) Abvr() (string, error) {
 }
 
 type Thumbnails struct {
+	Prefix        string // Folder in the object store, e.g. the workspace
 	EntityId      string
 	Discriminator string
 
@@ -100,12 +103,34 @@ func WithThumbnailEntityId(id string) NewThumbnailOption {
 	}
 }
 
+func WithThumbnailPrefix(prefix string) NewThumbnailOption {
+	return func(th *Thumbnails) {
+		th.Prefix = prefix
+	}
+}
+
 func WithThumbnailDiscriminator() NewThumbnailOption {
 	return func(th *Thumbnails) {
 		th.Discriminator = cuid.New()
 	}
 }
 
+// WithThumbnailBaseName
+// names the thumbnails like existing ones, e.g. to render them again in place.
+func WithThumbnailBaseName(baseName string) NewThumbnailOption {
+	return func(th *Thumbnails) {
+		prefix, name := "", baseName
+		if i := strings.LastIndex(baseName, "/"); i >= 0 {
+			prefix, name = baseName[:i], baseName[i+1:]
+		}
+		th.Prefix = prefix
+		th.EntityId, th.Discriminator = name, ""
+		if i := strings.LastIndex(name, "_"); i >= 0 {
+			th.EntityId, th.Discriminator = name[:i], name[i+1:]
+		}
+	}
+}
+
 func NewThumbnails(opts ...NewThumbnailOption) *Thumbnails {
 	th := &Thumbnails{}
 	for _, o := range opts {
@@ -114,8 +139,19 @@ func NewThumbnails(opts ...NewThumbnailOption) *Thumbnails {
 	return th
 }
 
-func NewThumbnailsFromMultipart(file multipart.File, entityId string) (*Thumbnails, error) {
-	jpegImg, err := convertFileToJpeg(file)
+func NewThumbnailsFromMultipart(file multipart.File, entityId string, opts ...NewThumbnailOption) (*Thumbnails, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return NewThumbnailsFromBytes(data, entityId, opts...)
+}
+
+// NewThumbnailsFromBytes
+// renders every size of a JPEG, PNG or HEIC image.
+func NewThumbnailsFromBytes(data []byte, entityId string, opts ...NewThumbnailOption) (*Thumbnails, error) {
+	jpegImg, err := decodeImage(data)
 	if err != nil {
 		return nil, err
 	}
@@ -160,11 +196,15 @@ func NewThumbnailsFromMultipart(file multipart.File, entityId string) (*Thumbnai
 
 	thumbnailOpt = append(thumbnailOpt, WithThumbnailEntityId(entityId))
 	thumbnailOpt = append(thumbnailOpt, WithThumbnailDiscriminator())
+	thumbnailOpt = append(thumbnailOpt, opts...)
 
 	return NewThumbnails(thumbnailOpt...), nil
 }
 
 func (t *Thumbnails) GetImageBaseName() string {
+	if t.Prefix != "" {
+		return fmt.Sprintf("%s/%s_%s", t.Prefix, t.EntityId, t.Discriminator)
+	}
 	return fmt.Sprintf("%s_%s", t.EntityId, t.Discriminator)
 }
 
@@ -185,3 +225,13 @@ func (t *Thumbnails) GetImageDataMap() *map[string][]byte {
 
 	return &res
 }
+
+// ImageNamesFromBaseName
+// returns the object names of every thumbnail size generated for the base name.
+func ImageNamesFromBaseName(baseName string) []string {
+	names := make([]string, 0, len(sizeToAbvrMap))
+	for _, abvr := range sizeToAbvrMap {
+		names = append(names, fmt.Sprintf("%s_%s.jpeg", baseName, abvr))
+	}
+	return names
+}
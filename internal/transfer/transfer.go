@@ -0,0 +1,325 @@
+package transfer
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/images"
+	"Backend/internal/models"
+	"Backend/internal/objectstore"
+	"Backend/internal/thumbnail"
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// An archive is a zip file holding manifest.json and, unless left out, every size of every
+// image below images/. Images are named without their workspace, so that an archive can be
+// imported into any workspace, of the same or of another installation.
+const (
+	manifestName   = "manifest.json"
+	imageDir       = "images/"
+	archiveVersion = 1
+)
+
+var ErrInvalidArchive = errors.New("invalid archive")
+
+type Manifest struct {
+	Version     int                           `json:"version"`
+	WorkspaceId string                        `json:"workspace_id"`
+	ExportedAt  time.Time                     `json:"exported_at"`
+	Attributes  []*models.AttributeDefinition `json:"attributes"`
+	Entities    []*Entity                     `json:"entities"` // Parents come before their children
+}
+
+// Entity
+// is an entity as it is archived, tags are given by name and images by base name without workspace.
+type Entity struct {
+	Id          string            `json:"id"`
+	ParentId    *string           `json:"parent_id"`
+	Code        *string           `json:"code"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Images      []string          `json:"images"`
+	Tags        []string          `json:"tags"`
+	Attributes  models.Attributes `json:"attributes"`
+	Quantity    int64             `json:"quantity"`
+	Unit        string            `json:"unit"`
+	MinQuantity *int64            `json:"min_quantity"`
+}
+
+////////////////////////////////////////////////
+// Export
+////////////////////////////////////////////////
+
+// Export
+// writes the live entities of the workspace in the context with their tags, attribute schema
+// and, when withImages is set, their images as an archive. The trash, history, loans and
+// stock adjustments are not part of it.
+func Export(
+	ctx context.Context,
+	db database.Repository,
+	objStore objectstore.ObjectStore,
+	w io.Writer,
+	withImages bool,
+) (*Manifest, error) {
+
+	workspaceId, ok := database.WorkspaceFromContext(ctx)
+	if !ok {
+		return nil, database.ErrNoWorkspace
+	}
+
+	attributes, err := db.QueryAttributeSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := db.QueryFiltered(ctx, database.PageRequest{Sort: database.SortByCreatedAt}, database.EntityFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{
+		Version:     archiveVersion,
+		WorkspaceId: workspaceId,
+		ExportedAt:  time.Now().UTC(),
+		Attributes:  attributes,
+		Entities:    make([]*Entity, 0, len(page.Entities)),
+	}
+	for _, e := range parentsFirst(page.Entities) {
+		manifest.Entities = append(manifest.Entities, archived(e))
+	}
+
+	archive := zip.NewWriter(w)
+
+	if withImages {
+		for _, e := range page.Entities {
+			for _, url := range e.Images {
+				if err := writeImage(ctx, archive, objStore, images.BaseName(url)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	f, err := archive.Create(manifestName)
+	if err != nil {
+		return nil, err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func archived(e *models.Entity) *Entity {
+	tags := make([]string, 0, len(e.Tags))
+	for _, t := range e.Tags {
+		tags = append(tags, t.Name)
+	}
+
+	imageNames := make([]string, 0, len(e.Images))
+	for _, url := range e.Images {
+		imageNames = append(imageNames, path.Base(images.BaseName(url)))
+	}
+
+	return &Entity{
+		Id:          e.Id,
+		ParentId:    e.ParentId,
+		Code:        e.Code,
+		Name:        e.Name,
+		Description: e.Description,
+		Images:      imageNames,
+		Tags:        tags,
+		Attributes:  e.Attributes,
+		Quantity:    e.Quantity,
+		Unit:        e.Unit,
+		MinQuantity: e.MinQuantity,
+	}
+}
+
+// parentsFirst
+// orders the entities so that every parent comes before its children, keeping the order otherwise.
+func parentsFirst(entities []*models.Entity) []*models.Entity {
+	byId := make(map[string]*models.Entity, len(entities))
+	for _, e := range entities {
+		byId[e.Id] = e
+	}
+
+	ordered := make([]*models.Entity, 0, len(entities))
+	placed := make(map[string]bool, len(entities))
+	var place func(e *models.Entity)
+	place = func(e *models.Entity) {
+		if placed[e.Id] {
+			return
+		}
+		placed[e.Id] = true
+		if e.ParentId != nil {
+			if parent, ok := byId[*e.ParentId]; ok {
+				place(parent)
+			}
+		}
+		ordered = append(ordered, e)
+	}
+	for _, e := range entities {
+		place(e)
+	}
+
+	return ordered
+}
+
+// writeImage
+// copies every stored size of the image into the archive, sizes that are missing are left out.
+func writeImage(ctx context.Context, archive *zip.Writer, objStore objectstore.ObjectStore, baseName string) error {
+	for _, name := range thumbnail.ImageNamesFromBaseName(baseName) {
+		r, err := objStore.RetrieveImage(ctx, name)
+		if err != nil {
+			if errors.Is(err, objectstore.ErrObjectNotFound) {
+				continue
+			}
+			return err
+		}
+
+		// Images are already compressed
+		f, err := archive.CreateHeader(&zip.FileHeader{Name: imageDir + path.Base(name), Method: zip.Store})
+		if err == nil {
+			_, err = io.Copy(f, r)
+		}
+		_ = r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+////////////////////////////////////////////////
+// Import
+////////////////////////////////////////////////
+
+// Import
+// creates the entities of the archive in the workspace of the context, keeping their ids and
+// codes so that printed labels stay valid, and uploads their images into the workspace.
+// The attribute schema of the archive is added to the one of the workspace. Entities are
+// created one by one, an import that fails part way leaves the ones before in place.
+func Import(
+	ctx context.Context,
+	db database.Repository,
+	objStore objectstore.ObjectStore,
+	r io.ReaderAt,
+	size int64,
+) (*Manifest, error) {
+
+	workspaceId, ok := database.WorkspaceFromContext(ctx)
+	if !ok {
+		return nil, database.ErrNoWorkspace
+	}
+
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	manifest, err := readManifest(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, def := range manifest.Attributes {
+		if err := db.UpsertAttributeDefinition(ctx, def); err != nil {
+			return nil, fmt.Errorf("unable to define attribute %s: %w", def.Key, err)
+		}
+	}
+
+	for _, e := range manifest.Entities {
+		urls := make([]string, 0, len(e.Images))
+		for _, name := range e.Images {
+			baseName := fmt.Sprintf("%s/%s", workspaceId, name)
+			if err := readImage(ctx, archive, objStore, name, baseName); err != nil {
+				return nil, fmt.Errorf("unable to import the images of entity %s: %w", e.Id, err)
+			}
+			urls = append(urls, fmt.Sprintf("/image/v1/%s.jpeg", baseName))
+		}
+
+		parentId, code := "", ""
+		if e.ParentId != nil {
+			parentId = *e.ParentId
+		}
+		if e.Code != nil {
+			code = *e.Code
+		}
+
+		entity := models.NewEntity(
+			models.EntityWithId(e.Id),
+			models.EntityWithName(e.Name),
+			models.EntityWithDescription(e.Description),
+			models.EntityWithParentId(parentId),
+			models.EntityWithImages(urls),
+			models.EntityWithTags(e.Tags),
+			models.EntityWithCode(code),
+			models.EntityWithAttributes(e.Attributes),
+			models.EntityWithQuantity(e.Quantity),
+			models.EntityWithUnit(e.Unit),
+			models.EntityWithMinQuantity(e.MinQuantity),
+		)
+		if err := db.CreateEntity(ctx, entity); err != nil {
+			return nil, fmt.Errorf("unable to create entity %s: %w", e.Id, err)
+		}
+	}
+
+	return manifest, nil
+}
+
+func readManifest(archive *zip.Reader) (*Manifest, error) {
+	f, err := archive.Open(manifestName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: no %s", ErrInvalidArchive, manifestName)
+	}
+	defer f.Close()
+
+	var manifest Manifest
+	if err := json.NewDecoder(f).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if manifest.Version != archiveVersion {
+		return nil, fmt.Errorf("%w: version %d is not supported", ErrInvalidArchive, manifest.Version)
+	}
+
+	return &manifest, nil
+}
+
+// readImage
+// uploads every size of the image found in the archive under the new base name.
+func readImage(ctx context.Context, archive *zip.Reader, objStore objectstore.ObjectStore, name string, baseName string) error {
+	for _, f := range archive.File {
+		size, ok := strings.CutPrefix(f.Name, imageDir+name+"_")
+		if !ok || strings.Contains(size, "_") || !strings.HasSuffix(size, ".jpeg") {
+			continue
+		}
+
+		r, err := f.Open()
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(r)
+		_ = r.Close()
+		if err != nil {
+			return err
+		}
+
+		if err := objStore.UploadImage(ctx, fmt.Sprintf("%s_%s", baseName, size), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package transfer
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/models"
+	"Backend/internal/objectstore"
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+// newWorkspace migrates a fresh SQLite database and returns a context scoped to a new workspace
+func newWorkspace(t *testing.T) (database.Repository, context.Context) {
+	t.Helper()
+
+	db, err := database.CreateGormSqliteAdapter(filepath.Join(t.TempDir(), "tt.db"))
+	if err != nil {
+		t.Fatalf("unable to create adapter: %v", err)
+	}
+	ctx := context.Background()
+	if err := db.Migrate(ctx); err != nil {
+		t.Fatalf("unable to migrate: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Disconnect(ctx) })
+
+	user, err := models.NewUser("alice", "correct horse")
+	if err != nil {
+		t.Fatalf("unable to create user: %v", err)
+	}
+	if err := db.CreateUser(ctx, user); err != nil {
+		t.Fatalf("unable to store user: %v", err)
+	}
+	workspace, err := db.CreateWorkspace(ctx, "Home", user.Id)
+	if err != nil {
+		t.Fatalf("unable to create workspace: %v", err)
+	}
+
+	return db, database.WithWorkspace(ctx, workspace.Id)
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	source, sourceCtx := newWorkspace(t)
+	sourceStore := objectstore.NewMemoryAdapter()
+	sourceWs, _ := database.WorkspaceFromContext(sourceCtx)
+
+	if err := source.UpsertAttributeDefinition(sourceCtx, &models.AttributeDefinition{
+		Key:  "voltage",
+		Type: models.AttributeTypeNumber,
+	}); err != nil {
+		t.Fatalf("unable to define attribute: %v", err)
+	}
+
+	imageName := sourceWs + "/drill_abc"
+	for _, size := range []string{"xs", "xl"} {
+		if err := sourceStore.UploadImage(sourceCtx, imageName+"_"+size+".jpeg", []byte(size)); err != nil {
+			t.Fatalf("unable to upload image: %v", err)
+		}
+	}
+
+	// The shelf is created after the drill it ends up holding
+	for _, e := range []*models.Entity{
+		models.NewEntity(models.EntityWithId("garage"), models.EntityWithName("Garage"), models.EntityWithCode("GARAGE01")),
+		models.NewEntity(
+			models.EntityWithId("drill"),
+			models.EntityWithName("Drill"),
+			models.EntityWithParentId("garage"),
+			models.EntityWithTags([]string{"tools"}),
+			models.EntityWithImages([]string{"/image/v1/" + imageName + ".jpeg"}),
+			models.EntityWithAttributes(models.Attributes{"voltage": 18.0}),
+			models.EntityWithQuantity(0),
+		),
+		models.NewEntity(models.EntityWithId("shelf"), models.EntityWithName("Shelf"), models.EntityWithParentId("garage")),
+	} {
+		if err := source.CreateEntity(sourceCtx, e); err != nil {
+			t.Fatalf("unable to create %s: %v", e.Id, err)
+		}
+	}
+	shelf := "shelf"
+	if _, err := source.MoveEntity(sourceCtx, "drill", &shelf); err != nil {
+		t.Fatalf("unable to move: %v", err)
+	}
+
+	var archive bytes.Buffer
+	if _, err := Export(sourceCtx, source, sourceStore, &archive, true); err != nil {
+		t.Fatalf("unable to export: %v", err)
+	}
+
+	target, targetCtx := newWorkspace(t)
+	targetStore := objectstore.NewMemoryAdapter()
+	targetWs, _ := database.WorkspaceFromContext(targetCtx)
+
+	manifest, err := Import(targetCtx, target, targetStore, bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		t.Fatalf("unable to import: %v", err)
+	}
+	if len(manifest.Entities) != 3 {
+		t.Errorf("expected 3 entities in the archive, got %d", len(manifest.Entities))
+	}
+
+	garage, err := target.QueryByCode(targetCtx, "GARAGE01")
+	if err != nil || garage.Id != "garage" {
+		t.Errorf("expected the garage to keep its code, got %v, %v", garage, err)
+	}
+
+	drill, err := target.QueryById(targetCtx, "drill")
+	if err != nil {
+		t.Fatalf("unable to query the imported drill: %v", err)
+	}
+	if drill.ParentId == nil || *drill.ParentId != "shelf" {
+		t.Errorf("expected the drill on the shelf, got parent %v", drill.ParentId)
+	}
+	if drill.Quantity != 0 || drill.Attributes["voltage"] != 18.0 {
+		t.Errorf("expected quantity 0 and voltage 18, got %d and %v", drill.Quantity, drill.Attributes["voltage"])
+	}
+	if len(drill.Tags) != 1 || drill.Tags[0].Name != "tools" {
+		t.Errorf("expected the tools tag, got %v", drill.Tags)
+	}
+
+	wantImage := targetWs + "/drill_abc"
+	if len(drill.Images) != 1 || drill.Images[0] != "/image/v1/"+wantImage+".jpeg" {
+		t.Fatalf("expected the image to move into the workspace, got %v", drill.Images)
+	}
+	r, err := targetStore.RetrieveImage(targetCtx, wantImage+"_xl.jpeg")
+	if err != nil {
+		t.Fatalf("unable to retrieve the imported image: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	if string(data) != "xl" {
+		t.Errorf("expected the image content to be kept, got %q", data)
+	}
+
+	schema, err := target.QueryAttributeSchema(targetCtx)
+	if err != nil || len(schema) != 1 || schema[0].Key != "voltage" {
+		t.Errorf("expected the attribute schema to be imported, got %v, %v", schema, err)
+	}
+}
@@ -0,0 +1,102 @@
+package trash
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/images"
+	"Backend/internal/label"
+	"Backend/internal/models"
+	"Backend/internal/objectstore"
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// Purge
+// permanently removes the entities of the workspace in the context that have been in the
+// trash for longer than the retention and deletes their thumbnails and cached labels from
+// the object store.
+func Purge(
+	ctx context.Context,
+	db database.Repository,
+	objStore objectstore.ObjectStore,
+	retention time.Duration,
+) ([]*models.Entity, error) {
+
+	purged, err := db.PurgeDeleted(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return nil, err
+	}
+
+	// The rows are gone at this point, a failed removal only leaves orphaned images behind
+	for _, e := range purged {
+		for _, imageUrl := range e.Images {
+			if err := objStore.DeleteThumbnail(ctx, images.BaseName(imageUrl)); err != nil {
+				log.Printf("[Error] Unable to delete thumbnails of entity %s: %v", e.Id, err)
+			}
+		}
+		if err := deleteLabels(ctx, objStore, e); err != nil {
+			log.Printf("[Error] Unable to delete labels of entity %s: %v", e.Id, err)
+		}
+	}
+
+	return purged, nil
+}
+
+// deleteLabels
+// removes every label rendered for the entity, one is cached per size and per change of what is printed.
+func deleteLabels(ctx context.Context, objStore objectstore.ObjectStore, e *models.Entity) error {
+	prefix := e.WorkspaceId + "/" + label.CachePrefix(e.Id)
+	labels, err := objStore.ListObjects(ctx, prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range labels {
+		// The prefix of an entity also starts the labels of ids continuing with an underscore
+		if strings.Contains(strings.TrimPrefix(l.Name, prefix), "_") {
+			continue
+		}
+		if err := objStore.DeleteImage(ctx, l.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunJanitor
+// purges the trash of every workspace on every interval until the context is cancelled.
+func RunJanitor(
+	ctx context.Context,
+	db database.Repository,
+	objStore objectstore.ObjectStore,
+	retention time.Duration,
+	interval time.Duration,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			workspaceIds, err := db.QueryWorkspaceIds(ctx)
+			if err != nil {
+				log.Printf("[Error] Unable to list workspaces: %v", err)
+				continue
+			}
+
+			for _, workspaceId := range workspaceIds {
+				purged, err := Purge(database.WithWorkspace(ctx, workspaceId), db, objStore, retention)
+				if err != nil {
+					log.Printf("[Error] Unable to purge trash of workspace %s: %v", workspaceId, err)
+					continue
+				}
+				if len(purged) > 0 {
+					log.Printf("Purged %v entities from the trash of workspace %s", len(purged), workspaceId)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package trash
+
+import (
+	"Backend/internal/database"
+	"Backend/internal/label"
+	"Backend/internal/models"
+	"Backend/internal/objectstore"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestPurgeDeletesCachedLabels(t *testing.T) {
+	db, err := database.CreateGormSqliteAdapter(filepath.Join(t.TempDir(), "tt.db"))
+	if err != nil {
+		t.Fatalf("unable to create adapter: %v", err)
+	}
+	ctx := context.Background()
+	if err := db.Migrate(ctx); err != nil {
+		t.Fatalf("unable to migrate: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Disconnect(ctx) })
+
+	user, err := models.NewUser("alice", "correct horse")
+	if err != nil {
+		t.Fatalf("unable to create user: %v", err)
+	}
+	if err := db.CreateUser(ctx, user); err != nil {
+		t.Fatalf("unable to store user: %v", err)
+	}
+	workspace, err := db.CreateWorkspace(ctx, "Home", user.Id)
+	if err != nil {
+		t.Fatalf("unable to create workspace: %v", err)
+	}
+	ctx = database.WithWorkspace(ctx, workspace.Id)
+
+	// The id of the kept entity continues that of the purged one with an underscore
+	for _, id := range []string{"drill", "drill_bits"} {
+		if err := db.CreateEntity(ctx, models.NewEntity(models.EntityWithId(id), models.EntityWithName(id))); err != nil {
+			t.Fatalf("unable to create %s: %v", id, err)
+		}
+	}
+	if err := db.DeleteEntity(ctx, "drill", false); err != nil {
+		t.Fatalf("unable to delete: %v", err)
+	}
+
+	objStore := objectstore.NewMemoryAdapter()
+	keys := map[string]string{}
+	for _, id := range []string{"drill", "drill_bits"} {
+		for _, size := range []int{label.MinSize, label.DefaultSize} {
+			l := label.NewLabel("https://tt.example.com/r/CODE", label.LabelWithName(id), label.LabelWithSize(size))
+			key := workspace.Id + "/" + l.CacheKey(id, "png")
+			if err := objStore.UploadObject(ctx, key, []byte("png"), "image/png"); err != nil {
+				t.Fatalf("unable to cache label: %v", err)
+			}
+			keys[key] = id
+		}
+	}
+
+	purged, err := Purge(ctx, db, objStore, 0)
+	if err != nil {
+		t.Fatalf("unable to purge: %v", err)
+	}
+	if len(purged) != 1 || purged[0].Id != "drill" {
+		t.Fatalf("expected only drill to be purged, got %v", purged)
+	}
+
+	for key, id := range keys {
+		_, err := objStore.RetrieveImage(ctx, key)
+		if id == "drill" && err == nil {
+			t.Errorf("expected the label %s of the purged entity to be deleted", key)
+		}
+		if id == "drill_bits" && err != nil {
+			t.Errorf("expected the label %s of the kept entity to remain, got %v", key, err)
+		}
+	}
+}